@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"appengine"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// archive tournament handler:
+//
+// Use this handler to archive a finished tournament.
+//	POST	/a/archive/tournament/
+//
+// The tournament is read again from the datastore so that a tournament is archived only once.
+func ArchiveTournament(w http.ResponseWriter, r *http.Request) error {
+	c := appengine.NewContext(r)
+	desc := "Task queue - Archive Tournament Handler:"
+
+	log.Infof(c, "%s processing...", desc)
+	if r.Method == "POST" {
+		tournamentBlob := []byte(r.FormValue("tournament"))
+
+		var t mdl.Tournament
+		if err := json.Unmarshal(tournamentBlob, &t); err != nil {
+			log.Errorf(c, "%s unable to extract tournament from data, %v", desc, err)
+			return err
+		}
+
+		log.Infof(c, "%s value of tournament id: %v", desc, t.Id)
+
+		tournament, err := mdl.TournamentById(c, t.Id)
+		if err != nil {
+			log.Errorf(c, "%s tournament not found: %v", desc, err)
+			return err
+		}
+		if !tournament.IsFinished(c) {
+			log.Infof(c, "%s tournament %d is not finished anymore, nothing to archive", desc, t.Id)
+			return nil
+		}
+		if err := tournament.Archive(c); err != nil {
+			log.Errorf(c, "%s unable to archive tournament: %v", desc, err)
+			return err
+		}
+		log.Infof(c, "%s task done!", desc)
+		return nil
+	}
+	log.Infof(c, "%s something went wrong...", desc)
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"appengine"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// auto predict handler:
+//
+// Use this handler to create the automatic predicts of a locked match.
+//	POST	/a/autopredict/
+//
+// An automatic predict is created for each opted-in participant of the tournament who did not predict the match.
+// The consensus of the match is then computed.
+func AutoPredict(w http.ResponseWriter, r *http.Request) error {
+	c := appengine.NewContext(r)
+	desc := "Task queue - Auto Predict Handler:"
+
+	log.Infof(c, "%s processing...", desc)
+	if r.Method == "POST" {
+		tournamentBlob := []byte(r.FormValue("tournament"))
+		matchBlob := []byte(r.FormValue("match"))
+
+		var t mdl.Tournament
+		if err := json.Unmarshal(tournamentBlob, &t); err != nil {
+			log.Errorf(c, "%s unable to extract tournament from data, %v", desc, err)
+			return err
+		}
+
+		var m mdl.Tmatch
+		if err := json.Unmarshal(matchBlob, &m); err != nil {
+			log.Errorf(c, "%s unable to extract match from data, %v", desc, err)
+			return err
+		}
+
+		log.Infof(c, "%s value of tournament id: %v", desc, t.Id)
+		log.Infof(c, "%s value of match id: %v", desc, m.Id)
+
+		predicts, err := t.AutoPredict(c, &m)
+		if err != nil {
+			log.Errorf(c, "%s unable to create automatic predicts: %v", desc, err)
+			return err
+		}
+		log.Infof(c, "%s %d automatic predicts created", desc, len(predicts))
+
+		// the consensus of the match includes the automatic predicts.
+		if err := t.UpdateConsensus(c, &m); err != nil {
+			log.Errorf(c, "%s unable to update consensus of match %v: %v", desc, m.Id, err)
+			return err
+		}
+		log.Infof(c, "%s task done!", desc)
+		return nil
+	}
+	log.Infof(c, "%s something went wrong...", desc)
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"appengine"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// Update users badges handler:
+//
+// Use this handler to evaluate the badge rules of users after a score update.
+//	POST	/a/update/users/badges/
+//
+// Each new badge is saved and published as an activity of the user.
+func UpdateUsersBadges(w http.ResponseWriter, r *http.Request) error {
+	c := appengine.NewContext(r)
+	desc := "Task queue - Update Users Badges Handler:"
+	log.Infof(c, "%s processing...", desc)
+
+	if r.Method == "POST" {
+		log.Infof(c, "%s reading data...", desc)
+		userIdsBlob := []byte(r.FormValue("userIds"))
+		tournamentBlob := []byte(r.FormValue("tournament"))
+		matchBlob := []byte(r.FormValue("match"))
+
+		var userIds []int64
+		if err := json.Unmarshal(userIdsBlob, &userIds); err != nil {
+			log.Errorf(c, "%s unable to extract userIds from data, %v", desc, err)
+			return err
+		}
+
+		var t mdl.Tournament
+		if err := json.Unmarshal(tournamentBlob, &t); err != nil {
+			log.Errorf(c, "%s unable to extract tournament from data, %v", desc, err)
+			return err
+		}
+
+		var m mdl.Tmatch
+		if err := json.Unmarshal(matchBlob, &m); err != nil {
+			log.Errorf(c, "%s unable to extract match from data, %v", desc, err)
+			return err
+		}
+
+		log.Infof(c, "%s value of user ids: %v", desc, userIds)
+		log.Infof(c, "%s value of tournament id: %v", desc, t.Id)
+		log.Infof(c, "%s value of match id: %v", desc, m.Id)
+
+		log.Infof(c, "%s crunching data...", desc)
+		for _, id := range userIds {
+			u, err := mdl.UserById(c, id)
+			if err != nil {
+				log.Errorf(c, "%s cannot find user with id=%v", desc, id)
+				continue
+			}
+			if badges, err := u.EarnBadges(c, &t, &m); err != nil {
+				log.Errorf(c, "%s unable to evaluate badges of user %v: %v", desc, id, err)
+			} else if len(badges) > 0 {
+				log.Infof(c, "%s user %v earned %d badges", desc, id, len(badges))
+			}
+		}
+		log.Infof(c, "%s task done!", desc)
+		return nil
+	}
+	log.Infof(c, "%s something went wrong...", desc)
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
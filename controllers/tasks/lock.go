@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+package tasks
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"appengine"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// lock matches handler:
+//
+// Use this handler to lock the predictions of the matches whose deadline has passed.
+//	GET	/a/lock/matches
+//
+// It is called by the cron service. Once a match is locked its crowd consensus is computed
+// and the automatic predicts of the users who missed the deadline are created.
+func LockMatches(w http.ResponseWriter, r *http.Request) error {
+	c := appengine.NewContext(r)
+	desc := "Cron - Lock Matches Handler:"
+
+	log.Infof(c, "%s processing...", desc)
+	if r.Method == "GET" {
+		count := mdl.LockDueMatches(c, time.Now())
+		log.Infof(c, "%s %d matches locked", desc, count)
+		log.Infof(c, "%s task done!", desc)
+		return nil
+	}
+	log.Infof(c, "%s something went wrong...", desc)
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"appengine"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// update questions scores handler:
+//
+// Use this handler to give the points of a resolved question to the users who gave the right answer.
+//	POST	/a/update/questions/scores/
+//
+// The points are added to the user score and to his tournament score, a user is credited only once per question.
+// Any failure is returned so the task is retried, users already credited are skipped.
+func UpdateQuestionsScores(w http.ResponseWriter, r *http.Request) error {
+	c := appengine.NewContext(r)
+	desc := "Task queue - Update Questions Scores Handler:"
+
+	log.Infof(c, "%s processing...", desc)
+	if r.Method == "POST" {
+		questionBlob := []byte(r.FormValue("question"))
+
+		var q mdl.Question
+		if err := json.Unmarshal(questionBlob, &q); err != nil {
+			log.Errorf(c, "%s unable to extract question from data, %v", desc, err)
+			return err
+		}
+		log.Infof(c, "%s value of question id: %v", desc, q.Id)
+
+		t, err := mdl.TournamentById(c, q.TournamentId)
+		if err != nil {
+			log.Errorf(c, "%s tournament %v not found: %v", desc, q.TournamentId, err)
+			return err
+		}
+
+		winners := q.Winners(mdl.AnswersByQuestion(c, q.Id))
+		log.Infof(c, "%s value of winners: %v", desc, winners)
+
+		count := 0
+		for _, u := range mdl.UsersByIds(c, winners) {
+			se, _ := u.TournamentScore(c, t)
+			if se == nil {
+				if se, err = mdl.CreateScore(c, u.Id, t.Id); err != nil {
+					log.Errorf(c, "%s unable to create score entity of user %v: %v", desc, u.Id, err)
+					return err
+				}
+				u.AddTournamentScore(c, se.Id, t.Id)
+				if err = u.Update(c); err != nil {
+					log.Errorf(c, "%s unable to update user %v: %v", desc, u.Id, err)
+					return err
+				}
+			}
+			credited, err := q.Credit(c, u.Id, se.Id)
+			if err != nil {
+				log.Errorf(c, "%s unable to credit user %v: %v", desc, u.Id, err)
+				return err
+			}
+			if credited {
+				count++
+			}
+		}
+		log.Infof(c, "%s %d users credited", desc, count)
+		log.Infof(c, "%s task done!", desc)
+		return nil
+	}
+	log.Infof(c, "%s something went wrong...", desc)
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
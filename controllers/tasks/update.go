@@ -77,11 +77,20 @@ func UpdateScores(w http.ResponseWriter, r *http.Request /*, u *mdl.User*/) erro
 		userIdsToCreateSE := make([]int64, 0)
 		tournamentId := t.Id
 
+		// side predicts of the match, scored in addition to the predicts.
+		sidePredicts := mdl.SidePredictsByUser(mdl.FindSidePredicts(c, "MatchId", m.Id))
+		// rarity bonus of the predicts, kept in the score breakdown.
+		rarityBonuses := t.RarityBonuses(c, &m)
+		bonuses := make([]int64, 0)
+		sides := make([]int64, 0)
+
 		for _, u := range users {
 			if score, err := u.ScoreForMatch(c, &m); err != nil {
 				log.Errorf(c, "%s unable udpate user %v score: %v", desc, u.Id, err)
 			} else {
 				scores = append(scores, score)
+				sides = append(sides, t.SideScore(&m, sidePredicts[u.Id]))
+				bonuses = append(bonuses, rarityBonuses[u.Id])
 				userIds = append(userIds, u.Id)
 			}
 			if scoreEntity, _ := u.TournamentScore(c, &t); scoreEntity == nil {
@@ -105,9 +114,14 @@ func UpdateScores(w http.ResponseWriter, r *http.Request /*, u *mdl.User*/) erro
 		if errm13 != nil {
 			log.Errorf(c, "%s Error marshaling", desc, errm13)
 		}
+		bsides, errm14 := json.Marshal(sides)
+		if errm14 != nil {
+			log.Errorf(c, "%s Error marshaling", desc, errm14)
+		}
 		task1 := taskqueue.NewPOSTTask("/a/update/users/scores/", url.Values{
 			"userIds":      []string{string(buserIds)},
 			"scores":       []string{string(bscores)},
+			"sides":        []string{string(bsides)},
 			"tournamentId": []string{string(btournamentId)},
 		})
 
@@ -156,10 +170,17 @@ func UpdateScores(w http.ResponseWriter, r *http.Request /*, u *mdl.User*/) erro
 		if errm2 != nil {
 			log.Errorf(c, "%s Error marshaling", desc, errm2)
 		}
+		bbonuses, errm32 := json.Marshal(bonuses)
+		if errm32 != nil {
+			log.Errorf(c, "%s Error marshaling", desc, errm32)
+		}
 		task3 := taskqueue.NewPOSTTask("/a/add/scoreentities/score/", url.Values{
 			"userIds":    []string{string(buserIds)},
 			"scores":     []string{string(bscores)},
+			"bonuses":    []string{string(bbonuses)},
+			"sides":      []string{string(bsides)},
 			"tournament": []string{string(tournamentBlob)},
+			"match":      []string{string(matchBlob)},
 		})
 
 		if _, err := taskqueue.Add(c, task3, "gw-queue"); err != nil {
@@ -185,6 +206,39 @@ func UpdateScores(w http.ResponseWriter, r *http.Request /*, u *mdl.User*/) erro
 		}
 		log.Infof(c, "%s task queue for publishing user score activities: <--", desc)
 
+		// task queue for updating predictor ratings of users.
+		log.Infof(c, "%s task queue for updating ratings of users: -->", desc)
+
+		task5 := taskqueue.NewPOSTTask("/a/update/users/ratings/", url.Values{
+			"userIds":    []string{string(buserIds)},
+			"tournament": []string{string(tournamentBlob)},
+			"match":      []string{string(matchBlob)},
+		})
+
+		if _, err := taskqueue.Add(c, task5, "gw-queue"); err != nil {
+			log.Errorf(c, "%s unable to add task to taskqueue.", desc)
+			return err
+		} else {
+			log.Infof(c, "%s add task to taskqueue successfully", desc)
+		}
+		log.Infof(c, "%s task queue for updating ratings of users: <--", desc)
+
+		// task queue for updating statistics of users.
+		log.Infof(c, "%s task queue for updating statistics of users: -->", desc)
+
+		task6 := taskqueue.NewPOSTTask("/a/update/users/stats/", url.Values{
+			"userIds": []string{string(buserIds)},
+			"match":   []string{string(matchBlob)},
+		})
+
+		if _, err := taskqueue.Add(c, task6, "gw-queue"); err != nil {
+			log.Errorf(c, "%s unable to add task to taskqueue.", desc)
+			return err
+		} else {
+			log.Infof(c, "%s add task to taskqueue successfully", desc)
+		}
+		log.Infof(c, "%s task queue for updating statistics of users: <--", desc)
+
 		log.Infof(c, "%s task done!", desc)
 		return nil
 	}
@@ -216,6 +270,11 @@ func UpdateUsersScores(w http.ResponseWriter, r *http.Request) error {
 			log.Errorf(c, "%s unable to extract scores from data, %v", desc, err2)
 		}
 
+		var sides []int64
+		if err := json.Unmarshal([]byte(r.FormValue("sides")), &sides); err != nil {
+			log.Errorf(c, "%s unable to extract side points from data, %v", desc, err)
+		}
+
 		log.Infof(c, "%s value of user ids: %v", desc, userIds)
 		log.Infof(c, "%s value of scores: %v", desc, scores)
 
@@ -227,6 +286,9 @@ func UpdateUsersScores(w http.ResponseWriter, r *http.Request) error {
 				log.Errorf(c, "%s cannot find user with id=%v", desc, id)
 			} else {
 				u.Score += scores[i]
+				if i < len(sides) {
+					u.Score += sides[i]
+				}
 				usersToUpdate = append(usersToUpdate, u)
 			}
 		}
@@ -235,6 +297,9 @@ func UpdateUsersScores(w http.ResponseWriter, r *http.Request) error {
 			log.Errorf(c, "%s unable udpate users scores: %v", desc, err)
 			return errors.New(helpers.ErrorCodeUsersCannotUpdate)
 		}
+		if err := mdl.UpdateTeamsLeader(c, usersToUpdate); err != nil {
+			log.Errorf(c, "%s unable to update teams leader: %v", desc, err)
+		}
 		log.Infof(c, "%s task done!", desc)
 		return nil
 	}
@@ -337,6 +402,16 @@ func AddScoreToScoreEntities(w http.ResponseWriter, r *http.Request) error {
 			log.Errorf(c, "%s unable to extract userIds from data, %v", desc, err1)
 		}
 
+		var bonuses []int64
+		if err := json.Unmarshal([]byte(r.FormValue("bonuses")), &bonuses); err != nil {
+			log.Errorf(c, "%s unable to extract bonuses from data, %v", desc, err)
+		}
+
+		var sides []int64
+		if err := json.Unmarshal([]byte(r.FormValue("sides")), &sides); err != nil {
+			log.Errorf(c, "%s unable to extract side points from data, %v", desc, err)
+		}
+
 		log.Infof(c, "%s value of user ids: %v", desc, userIds)
 		log.Infof(c, "%s value of scores: %v", desc, scores)
 		log.Infof(c, "%s value of tournament id: %v", desc, t.Id)
@@ -365,10 +440,26 @@ func AddScoreToScoreEntities(w http.ResponseWriter, r *http.Request) error {
 		}
 
 		log.Infof(c, "%s add scores", desc)
-		if err := mdl.AddScores(c, tournamentScores, scores); err != nil {
+		if err := mdl.AddScores(c, tournamentScores, scores, bonuses, sides); err != nil {
 			log.Errorf(c, "%s cannot add scores to score entities. %v", desc, err)
 			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeInternal)}
 		}
+
+		// scores are up to date, task queue for evaluating badges of users.
+		log.Infof(c, "%s task queue for evaluating badges of users: -->", desc)
+		task := taskqueue.NewPOSTTask("/a/update/users/badges/", url.Values{
+			"userIds":    []string{string(userIdsBlob)},
+			"tournament": []string{string(tournamentBlob)},
+			"match":      []string{r.FormValue("match")},
+		})
+
+		if _, err := taskqueue.Add(c, task, "gw-queue"); err != nil {
+			log.Errorf(c, "%s unable to add task to taskqueue.", desc)
+			return err
+		} else {
+			log.Infof(c, "%s add task to taskqueue successfully", desc)
+		}
+		log.Infof(c, "%s task queue for evaluating badges of users: <--", desc)
 		log.Infof(c, "%s task done!", desc)
 		return nil
 	}
@@ -83,6 +83,8 @@ func Accuracies(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 //	GET	/j/teams/:teamId/accuracies/:tournamentId	retrieves accuracies of a team with the given id for the specified tournament.
 //
 // The response is an array of accurracies for the specified team team group by tournament with all it's progressions.
+// It also contains the time series of the team, one point per finished match with its date and the members-averaged points,
+// as well as the contribution of each member for each point of the time series.
 func AccuracyByTournament(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 	c := appengine.NewContext(r)
 	desc := "Team Accuracies by tournament Handler:"
@@ -140,3 +142,74 @@ func AccuracyByTournament(w http.ResponseWriter, r *http.Request, u *mdl.User) e
 	}
 	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
 }
+
+// Team rarity bonus handler:
+//
+// Use this handler to enable or disable the rarity bonus of a team in a tournament.
+//	POST	/j/teams/:teamId/raritybonus/:tournamentId?enabled=true
+//
+// When enabled, the rarity bonus earned by the members of the team is part of the team accuracy in the tournament.
+// Only the team administrators can change this setting.
+func RarityBonus(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Team rarity bonus Handler:"
+
+	if r.Method == "POST" {
+		// get team id and tournament id
+		strTeamId, err := route.Context.Get(r, "teamId")
+		if err != nil {
+			log.Errorf(c, "%s error getting team id, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTeamNotFound)}
+		}
+
+		var teamId int64
+		teamId, err = strconv.ParseInt(strTeamId, 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting team id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTeamNotFound)}
+		}
+
+		strTournamentId, err := route.Context.Get(r, "tournamentId")
+		if err != nil {
+			log.Errorf(c, "%s error getting tournament id, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournamentId int64
+		tournamentId, err = strconv.ParseInt(strTournamentId, 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting tournament id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var team *mdl.Team
+		if team, err = mdl.TeamById(c, teamId); err != nil {
+			log.Errorf(c, "%s team not found: %v", desc, err)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTeamNotFound)}
+		}
+
+		if !mdl.IsTeamAdmin(c, team.Id, u.Id) {
+			log.Errorf(c, "%s user is not admin of team %v", desc, team.Id)
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeTeamUpdateForbiden)}
+		}
+
+		if ok, _ := team.ContainsTournamentId(tournamentId); !ok {
+			log.Errorf(c, "%s team %v does not participate in tournament %v", desc, team.Id, tournamentId)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		enabled := r.FormValue("enabled") != "false"
+		if err = team.SetRarityBonus(c, tournamentId, enabled); err != nil {
+			log.Errorf(c, "%s unable to update team: %v", desc, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeTeamCannotUpdate)}
+		}
+
+		data := struct {
+			RarityBonus bool
+		}{
+			team.HasRarityBonus(tournamentId),
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
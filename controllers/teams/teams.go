@@ -57,9 +57,11 @@ import (
 )
 
 type TeamData struct {
-	Name        string
-	Description string
-	Visibility  string
+	Name           string
+	Description    string
+	Visibility     string
+	RevealPredicts bool
+	AutoPredictCut int64
 }
 
 type PriceData struct {
@@ -230,7 +232,7 @@ func Show(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 
 		// build team json
 		var tJson mdl.TeamJson
-		fieldsToKeep := []string{"Id", "Name", "Description", "AdminIds", "Private", "TournamentIds", "Accuracy"}
+		fieldsToKeep := []string{"Id", "Name", "Description", "AdminIds", "Private", "TournamentIds", "Accuracy", "RevealPredicts", "AutoPredictCut"}
 		helpers.InitPointerStructure(team, &tJson, fieldsToKeep)
 
 		// build players json
@@ -345,8 +347,14 @@ func Update(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 		log.Errorf(c, "%s visibility %v.", desc, updatedData.Visibility)
 		log.Errorf(c, "%s updateddata %v.", desc, updatedData)
 
+		if updatedData.AutoPredictCut < 0 || updatedData.AutoPredictCut > 100 {
+			log.Errorf(c, "%s auto predict cut is not a percentage: %v", desc, updatedData.AutoPredictCut)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTeamCannotUpdate)}
+		}
+
 		if helpers.IsStringValid(updatedData.Name) &&
-			(updatedData.Name != team.Name || updatedData.Description != team.Description || updatedPrivate != team.Private) {
+			(updatedData.Name != team.Name || updatedData.Description != team.Description || updatedPrivate != team.Private ||
+				updatedData.RevealPredicts != team.RevealPredicts || updatedData.AutoPredictCut != team.AutoPredictCut) {
 			if updatedData.Name != team.Name {
 				// be sure that a team with that name does not exist in datastore.
 				if t := mdl.FindTeams(c, "KeyName", helpers.TrimLower(updatedData.Name)); t != nil {
@@ -358,6 +366,8 @@ func Update(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			}
 			team.Description = updatedData.Description
 			team.Private = updatedPrivate
+			team.RevealPredicts = updatedData.RevealPredicts
+			team.AutoPredictCut = updatedData.AutoPredictCut
 			team.Update(c)
 		} else {
 			log.Errorf(c, "%s Cannot update because updated is not valid.", desc)
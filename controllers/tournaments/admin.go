@@ -23,6 +23,8 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"appengine"
 	"appengine/taskqueue"
@@ -78,6 +80,10 @@ func AddAdmin(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
 		newAdmin, err := mdl.UserById(c, userId)
 		log.Infof(c, "%s User: %v", desc, newAdmin)
 		if err != nil {
@@ -151,6 +157,10 @@ func RemoveAdmin(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
 		var oldAdmin *mdl.User
 		oldAdmin, err := mdl.UserById(c, userId)
 		log.Infof(c, "%s User: %v.", desc, oldAdmin)
@@ -211,6 +221,11 @@ func SyncScores(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			log.Errorf(c, "%s tournament not found: %v", desc, err)
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
 		// prepare data to add task to queue.
 		b1, errm := json.Marshal(tournament)
 		if errm != nil {
@@ -271,6 +286,10 @@ func ActivatePhase(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
 		phaseName := r.FormValue("phaseName")
 
 		matches := mdl.GetMatchesByPhase(c, tournament, phaseName)
@@ -283,3 +302,288 @@ func ActivatePhase(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 	}
 	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
 }
+
+// Tournament lock policy handler:
+//
+// Use this handler to set the deadline policy of the predictions of a tournament.
+//	POST	/j/tournaments/[0-9]+/admin/lockpolicy?policy=phase&offset=60
+//
+// policy is one of match, matchday, phase or tournament.
+// offset is the number of minutes before kickoff the predictions are locked.
+// The lock date of all the matches of the tournament is updated accordingly.
+func LockPolicy(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament lock policy handler:"
+
+	if r.Method == "POST" {
+		// get tournament id
+		strTournamentId, err := route.Context.Get(r, "tournamentId")
+		if err != nil {
+			log.Errorf(c, "%s error getting tournament id, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournamentId int64
+		tournamentId, err = strconv.ParseInt(strTournamentId, 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting tournament id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournament *mdl.Tournament
+		if tournament, err = mdl.TournamentById(c, tournamentId); err != nil {
+			log.Errorf(c, "%s tournament not found: %v", desc, err)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
+		policy := r.FormValue("policy")
+		if !mdl.IsLockPolicyValid(policy) {
+			log.Errorf(c, "%s invalid lock policy: %v", desc, policy)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentInvalidLockPolicy)}
+		}
+
+		var offset int64
+		if strOffset := r.FormValue("offset"); len(strOffset) > 0 {
+			if offset, err = strconv.ParseInt(strOffset, 0, 64); err != nil || offset < 0 {
+				log.Errorf(c, "%s invalid lock offset: %v", desc, strOffset)
+				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentInvalidLockPolicy)}
+			}
+		}
+
+		if err = tournament.SetLockPolicy(c, policy, offset); err != nil {
+			log.Errorf(c, "%s unable to set lock policy: %v", desc, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeTournamentCannotUpdate)}
+		}
+
+		var tJson mdl.TournamentJson
+		fieldsToKeep := []string{"Id", "Name", "LockPolicy", "LockOffset"}
+		helpers.InitPointerStructure(tournament, &tJson, fieldsToKeep)
+
+		msg := fmt.Sprintf("The deadline policy of tournament %s is now %s.", tournament.Name, policy)
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			Tournament  mdl.TournamentJson
+		}{
+			msg,
+			tJson,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Tournament markets handler:
+//
+// Use this handler to switch on the side markets of a tournament.
+//	POST	/j/tournaments/[0-9]+/admin/markets?markets=overunder,btts&goalsline=2.5
+//
+// markets is a comma separated list of overunder, btts, margin and firstscorer, an empty list switches off all markets.
+// goalsline is the goals line of the over/under market, 2.5 by default.
+func Markets(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament markets handler:"
+
+	if r.Method == "POST" {
+		// get tournament id
+		strTournamentId, err := route.Context.Get(r, "tournamentId")
+		if err != nil {
+			log.Errorf(c, "%s error getting tournament id, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournamentId int64
+		tournamentId, err = strconv.ParseInt(strTournamentId, 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting tournament id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournament *mdl.Tournament
+		if tournament, err = mdl.TournamentById(c, tournamentId); err != nil {
+			log.Errorf(c, "%s tournament not found: %v", desc, err)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
+		markets := make([]string, 0)
+		if strMarkets := r.FormValue("markets"); len(strMarkets) > 0 {
+			for _, m := range strings.Split(strMarkets, ",") {
+				if !mdl.IsMarketValid(m) {
+					log.Errorf(c, "%s invalid market: %v", desc, m)
+					return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentInvalidMarkets)}
+				}
+				markets = append(markets, m)
+			}
+		}
+
+		goalsLine := tournament.OverUnderLine()
+		if strGoalsLine := r.FormValue("goalsline"); len(strGoalsLine) > 0 {
+			if goalsLine, err = strconv.ParseFloat(strGoalsLine, 64); err != nil || goalsLine <= 0 {
+				log.Errorf(c, "%s invalid goals line: %v", desc, strGoalsLine)
+				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentInvalidMarkets)}
+			}
+		}
+
+		tournament.Markets = markets
+		tournament.GoalsLine = goalsLine
+		if err = tournament.Update(c); err != nil {
+			log.Errorf(c, "%s unable to update tournament: %v", desc, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeTournamentCannotUpdate)}
+		}
+
+		var tJson mdl.TournamentJson
+		fieldsToKeep := []string{"Id", "Name", "Markets", "GoalsLine"}
+		helpers.InitPointerStructure(tournament, &tJson, fieldsToKeep)
+
+		msg := fmt.Sprintf("The markets of tournament %s were correctly updated!", tournament.Name)
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			Tournament  mdl.TournamentJson
+		}{
+			msg,
+			tJson,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Wagering handler:
+//
+// Use this handler to switch on or off the wagering mode of a tournament.
+//	POST	/j/tournaments/[0-9]+/admin/wagering?enabled=true&credits=1000
+//
+// In wagering mode each participant gets credits to stake on the outcome of the matches, 1000 by default.
+func Wagering(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament wagering handler:"
+
+	if r.Method == "POST" {
+		// get tournament id
+		strTournamentId, err := route.Context.Get(r, "tournamentId")
+		if err != nil {
+			log.Errorf(c, "%s error getting tournament id, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournamentId int64
+		tournamentId, err = strconv.ParseInt(strTournamentId, 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting tournament id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournament *mdl.Tournament
+		if tournament, err = mdl.TournamentById(c, tournamentId); err != nil {
+			log.Errorf(c, "%s tournament not found: %v", desc, err)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
+		credits := tournament.Credits()
+		if strCredits := r.FormValue("credits"); len(strCredits) > 0 {
+			if credits, err = strconv.ParseInt(strCredits, 0, 64); err != nil || credits <= 0 {
+				log.Errorf(c, "%s invalid credits: %v", desc, strCredits)
+				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentInvalidCredits)}
+			}
+		}
+
+		tournament.Wagering = r.FormValue("enabled") == "true"
+		tournament.InitialCredits = credits
+		if err = tournament.Update(c); err != nil {
+			log.Errorf(c, "%s unable to update tournament: %v", desc, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeTournamentCannotUpdate)}
+		}
+
+		var tJson mdl.TournamentJson
+		fieldsToKeep := []string{"Id", "Name", "Wagering", "InitialCredits"}
+		helpers.InitPointerStructure(tournament, &tJson, fieldsToKeep)
+
+		msg := fmt.Sprintf("The wagering mode of tournament %s was correctly updated!", tournament.Name)
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			Tournament  mdl.TournamentJson
+		}{
+			msg,
+			tJson,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Clone handler:
+//
+// Use this handler to create the next season of a tournament.
+//	POST	/j/tournaments/[0-9]+/admin/clone?name=2015-2016 UEFA Champions League&start=2016-04-05&teams=true
+//
+// The new tournament has the groups, phases, rules, teams, venues and settings of the tournament,
+// its matches are shifted to the new start date, without results nor predicts.
+// With teams=true the teams of the tournament join the new one with their members and price descriptions.
+func Clone(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament clone handler:"
+
+	if r.Method == "POST" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimSpace(r.FormValue("name"))
+		if len(name) == 0 {
+			log.Errorf(c, "%s 'name' field cannot be empty", desc)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNameCannotBeEmpty)}
+		}
+		if t := mdl.FindTournaments(c, "KeyName", helpers.TrimLower(name)); t != nil {
+			log.Errorf(c, "%s That tournament name already exists.", desc)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentAlreadyExists)}
+		}
+
+		start, err := time.Parse("2006-01-02", r.FormValue("start"))
+		if err != nil {
+			log.Errorf(c, "%s invalid start date: %v", desc, r.FormValue("start"))
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentInvalidStart)}
+		}
+
+		clone, err := tournament.Clone(c, name, start, u.Id)
+		if err != nil {
+			log.Errorf(c, "%s unable to clone tournament %d: %v", desc, tournament.Id, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeTournamentCannotClone)}
+		}
+
+		if r.FormValue("teams") == "true" {
+			if err := clone.CloneTeams(c, tournament); err != nil {
+				log.Errorf(c, "%s unable to enroll teams in tournament %d: %v", desc, clone.Id, err)
+				return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeTournamentCannotClone)}
+			}
+		}
+
+		u.Publish(c, "tournament", "created a tournament", clone.Entity(), mdl.ActivityEntity{})
+
+		var tJson mdl.TournamentJson
+		fieldsToKeep := []string{"Id", "Name", "Start", "End", "Format"}
+		helpers.InitPointerStructure(clone, &tJson, fieldsToKeep)
+
+		msg := fmt.Sprintf("The tournament %s was correctly cloned into %s!", tournament.Name, clone.Name)
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			Tournament  mdl.TournamentJson
+		}{
+			msg,
+			tJson,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"appengine"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// Archive handler:
+//
+// Use this handler to archive a finished tournament now, instead of a day after its last result.
+//	POST	/j/tournaments/[0-9]+/admin/archive
+//
+// The rankings are frozen into the final standings and the tournament becomes read-only.
+func Archive(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament archive handler:"
+
+	if r.Method == "POST" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		if tournament.Archived {
+			log.Errorf(c, "%s tournament %d is already archived", desc, tournament.Id)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentArchived)}
+		}
+		if !tournament.IsFinished(c) {
+			log.Errorf(c, "%s tournament %d is not finished", desc, tournament.Id)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFinished)}
+		}
+
+		if err := tournament.Archive(c); err != nil {
+			log.Errorf(c, "%s unable to archive tournament: %v", desc, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeTournamentCannotArchive)}
+		}
+
+		var tJson mdl.TournamentJson
+		fieldsToKeep := []string{"Id", "Name", "Archived"}
+		helpers.InitPointerStructure(tournament, &tJson, fieldsToKeep)
+
+		msg := fmt.Sprintf("The tournament %s was correctly archived!", tournament.Name)
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			Tournament  mdl.TournamentJson
+		}{
+			msg,
+			tJson,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Check that a tournament can be changed, an archived tournament is read-only.
+func checkNotArchived(c appengine.Context, t *mdl.Tournament, desc string) error {
+	if t.Archived {
+		log.Errorf(c, "%s tournament %d is archived", desc, t.Id)
+		return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeTournamentArchived)}
+	}
+	return nil
+}
+
+// Archived tournament handler:
+//
+// Use this handler to get the read-only view of an archived tournament.
+//	GET	/j/tournaments/[0-9]+/archive
+//
+// The response holds the final standings, the groups and the matches with the predicts of the current user.
+func Archived(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament archived handler:"
+
+	if r.Method == "GET" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		if !tournament.Archived {
+			log.Errorf(c, "%s tournament %d is not archived", desc, tournament.Id)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotArchived)}
+		}
+
+		standings, err := mdl.FinalStandingsByTournament(c, tournament.Id)
+		if err != nil {
+			log.Errorf(c, "%s final standings not found: %v", desc, err)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tJson mdl.TournamentJson
+		fieldsToKeep := []string{"Id", "Name", "Description", "Start", "End", "Archived"}
+		helpers.InitPointerStructure(tournament, &tJson, fieldsToKeep)
+
+		// the predicts of an archived tournament are in the archived predicts of the user.
+		reader := *u
+		reader.PredictIds = u.AllPredictIds()
+
+		data := struct {
+			Tournament mdl.TournamentJson
+			Standings  *mdl.FinalStandings
+			Groups     []GroupJson
+			Matches    []MatchJson
+		}{
+			tJson,
+			standings,
+			formatGroupsJson(c, mdl.Groups(c, tournament.GroupIds)),
+			buildMatchesFromTournament(c, tournament, &reader),
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
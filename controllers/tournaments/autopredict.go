@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"appengine"
+
+	"github.com/taironas/route"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// Auto predict handler:
+//
+// Use this handler to get or set the auto predict setting of the current user in a tournament.
+//	GET	/j/tournaments/[0-9]+/autopredict
+//	POST	/j/tournaments/[0-9]+/autopredict?strategy=fixed&result1=1&result2=0&enabled=true
+//
+// Available strategies are fixed, crowd, ranking and random.
+// The result1 and result2 parameters hold the scoreline of the fixed strategy,
+// it is also used when the crowd strategy has no predict to follow.
+// When a match is locked, opted-in users who did not predict it get an automatic predict.
+func AutoPredict(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Auto Predict Handler:"
+
+	// get tournament id
+	strTournamentId, err := route.Context.Get(r, "tournamentId")
+	if err != nil {
+		log.Errorf(c, "%s error getting tournament id, err:%v", desc, err)
+		return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+	}
+
+	var tournamentId int64
+	tournamentId, err = strconv.ParseInt(strTournamentId, 0, 64)
+	if err != nil {
+		log.Errorf(c, "%s error converting tournament id from string to int64, err:%v", desc, err)
+		return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+	}
+
+	var tournament *mdl.Tournament
+	tournament, err = mdl.TournamentById(c, tournamentId)
+	if err != nil {
+		log.Errorf(c, "%s tournament with id:%v was not found %v", desc, tournamentId, err)
+		return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+	}
+
+	autoPredict := mdl.AutoPredictByUserTournament(c, u.Id, tournament.Id)
+
+	if r.Method == "GET" {
+		data := struct {
+			AutoPredict *mdl.AutoPredict
+		}{
+			autoPredict,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	} else if r.Method == "POST" {
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkNotSandbox(c, tournament, desc); err != nil {
+			return err
+		}
+
+		// check if user joined the tournament
+		if !tournament.Joined(c, u) {
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotAllowedToSetPrediction)}
+		}
+
+		strategy := r.FormValue("strategy")
+		if !mdl.IsAutoPredictStrategyValid(strategy) {
+			log.Errorf(c, "%s invalid strategy: %v", desc, strategy)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeAutoPredictInvalidStrategy)}
+		}
+
+		var r1, r2 int64
+		if len(r.FormValue("result1")) > 0 || len(r.FormValue("result2")) > 0 {
+			if r1, err = strconv.ParseInt(r.FormValue("result1"), 0, 64); err != nil || r1 < 0 {
+				log.Errorf(c, "%s unable to get results, error: %v not number 1", desc, err)
+				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeCannotSetPrediction)}
+			}
+			if r2, err = strconv.ParseInt(r.FormValue("result2"), 0, 64); err != nil || r2 < 0 {
+				log.Errorf(c, "%s unable to get results, error: %v not number 2", desc, err)
+				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeCannotSetPrediction)}
+			}
+		}
+
+		enabled := r.FormValue("enabled") != "false"
+
+		if autoPredict == nil {
+			if autoPredict, err = mdl.CreateAutoPredict(c, u.Id, tournament.Id, strategy, r1, r2); err != nil {
+				log.Errorf(c, "%s unable to create auto predict: %v", desc, err)
+				return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeAutoPredictCannotUpdate)}
+			}
+			autoPredict.Enabled = enabled
+		} else {
+			autoPredict.Strategy = strategy
+			autoPredict.Result1 = r1
+			autoPredict.Result2 = r2
+			autoPredict.Enabled = enabled
+		}
+		if err = autoPredict.Update(c); err != nil {
+			log.Errorf(c, "%s unable to update auto predict: %v", desc, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeAutoPredictCannotUpdate)}
+		}
+
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			AutoPredict *mdl.AutoPredict
+		}{
+			"Your auto predict setting is now updated.",
+			autoPredict,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"appengine"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// A BracketTieJson is a variable to hold a tie of the knockout bracket of a tournament.
+type BracketTieJson struct {
+	Id         int64
+	IdNumber   int64
+	Phase      string
+	Date       time.Time
+	Location   string
+	Rule1      string // where the first team comes from, e.g. 1A or W49.
+	Rule2      string // where the second team comes from.
+	Team1      string // empty until the team is known.
+	Team2      string
+	Iso1       string
+	Iso2       string
+	Result1    int64
+	Result2    int64
+	Finished   bool
+	Winner     string // empty until the match is finished.
+	HasPredict bool
+	Predict    string
+	Ties       []BracketTieJson // ties whose winners play this tie.
+}
+
+// Tournament bracket handler:
+//
+// Use this handler to get the knockout bracket of a tournament.
+//	GET	/j/tournaments/[0-9]+/bracket
+//
+// The response is an array of trees of ties, the final first. Each tie holds the ties its teams come from.
+// Only single-leg formats are supported, the ties of a two-legged format are returned as a flat list.
+func Bracket(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Bracket Handler:"
+
+	if r.Method == "GET" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		tb := mdl.GetTournamentBuilder(tournament)
+		mapIdTeams := tb.MapOfIdTeams(c, tournament)
+		mapTeamCodes := tb.MapOfTeamCodes()
+		userPredicts, err := mdl.PredictsByIds2(c, u.PredictIds)
+		if err != nil {
+			log.Errorf(c, "%s unable to get predicts of user %v: %v", desc, u.Id, err)
+		}
+		predicts := mdl.Predicts(userPredicts)
+
+		var build func(tie *mdl.BracketTie) BracketTieJson
+		build = func(tie *mdl.BracketTie) BracketTieJson {
+			m := tie.Match
+			tj := BracketTieJson{
+				Id:       m.Id,
+				IdNumber: m.IdNumber,
+				Phase:    tie.Phase,
+				Date:     m.Date,
+				Location: m.Location,
+				Rule1:    tie.Rule1,
+				Rule2:    tie.Rule2,
+				Result1:  m.Result1,
+				Result2:  m.Result2,
+				Finished: m.Finished,
+				Ties:     make([]BracketTieJson, len(tie.Ties)),
+			}
+			if m.TeamId1 > 0 {
+				tj.Team1 = mapIdTeams[m.TeamId1]
+				tj.Iso1 = mapTeamCodes[tj.Team1]
+			}
+			if m.TeamId2 > 0 {
+				tj.Team2 = mapIdTeams[m.TeamId2]
+				tj.Iso2 = mapTeamCodes[tj.Team2]
+			}
+			if winner := m.Winner(); winner > 0 {
+				tj.Winner = mapIdTeams[winner]
+			}
+			if hasMatch, j := predicts.ContainsMatchId(m.Id); hasMatch == true {
+				tj.HasPredict = true
+				tj.Predict = fmt.Sprintf("%v - %v", predicts[j].Result1, predicts[j].Result2)
+			}
+			for i, child := range tie.Ties {
+				tj.Ties[i] = build(child)
+			}
+			return tj
+		}
+
+		roots := tournament.Bracket(c)
+		bracket := make([]BracketTieJson, len(roots))
+		for i, tie := range roots {
+			bracket[i] = build(tie)
+		}
+
+		data := struct {
+			Bracket []BracketTieJson
+		}{
+			bracket,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
@@ -57,6 +57,7 @@ type UserPredictionJson struct {
 	Username string
 	Alias    string
 	Predict  string
+	Hidden   bool // predict exists but is hidden until the match is locked.
 }
 
 // A PhaseJson is a variable to hold a the name of a phase and an array of days.
@@ -145,6 +146,7 @@ func Calendar(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 // * the date
 // by default the data returned is grouped by days.This means we will return an array of days, each of which can have an array of matches.
 // the 'groupby' parameter does not support 'phases' yet.
+// Predictions of other players are hidden until the match is locked, unless the team reveals them earlier.
 func CalendarWithPrediction(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 	c := appengine.NewContext(r)
 	desc := "Tournament Calendar with prediction Handler:"
@@ -192,6 +194,12 @@ func CalendarWithPrediction(w http.ResponseWriter, r *http.Request, u *mdl.User)
 		}
 		players := team.Players(c)
 
+		// predicts of other members are only visible once the match is locked.
+		mapMatches := make(map[int64]*mdl.Tmatch)
+		for _, m := range mdl.GetAllMatchesFromTournament(c, t) {
+			mapMatches[m.Id] = m
+		}
+
 		predictsByPlayer := make([]mdl.Predicts, len(players))
 		for i, p := range players {
 			predicts := mdl.PredictsByIds(c, p.PredictIds)
@@ -222,7 +230,12 @@ func CalendarWithPrediction(w http.ResponseWriter, r *http.Request, u *mdl.User)
 						uwp[k].Username = p.Username
 						uwp[k].Alias = p.Alias
 						if hasMatch, l := predictsByPlayer[k].ContainsMatchId(m.Id); hasMatch == true {
-							uwp[k].Predict = fmt.Sprintf("%v - %v", predictsByPlayer[k][l].Result1, predictsByPlayer[k][l].Result2)
+							if match, ok := mapMatches[m.Id]; ok && predictsByPlayer[k][l].IsVisibleBy(u.Id, match, team) {
+								uwp[k].Predict = fmt.Sprintf("%v - %v", predictsByPlayer[k][l].Result1, predictsByPlayer[k][l].Result2)
+							} else {
+								uwp[k].Predict = "?"
+								uwp[k].Hidden = true
+							}
 						} else {
 							uwp[k].Predict = "-"
 						}
@@ -292,14 +305,15 @@ func matchesGroupByPhase(t *mdl.Tournament, matches []MatchJson) []PhaseJson {
 }
 
 // From an array of matches, create an array of Days where the matches are grouped in.
-// We use the Date of each match to do this.
+// We use the Day of each match to do this: the matches have no kickoff time, so their day is the one at their venue
+// whatever the timezone of the user.
 func matchesGroupByDay(t *mdl.Tournament, matches []MatchJson) []DayJson {
 
 	mapOfDays := make(map[string][]MatchJson)
 
 	const shortForm = "Jan/02/2006"
 	for _, m := range matches {
-		currentDate := m.Date.Format(shortForm)
+		currentDate := m.Day.Format(shortForm)
 		_, ok := mapOfDays[currentDate]
 		if ok {
 			mapOfDays[currentDate] = append(mapOfDays[currentDate], m)
@@ -313,8 +327,8 @@ func matchesGroupByDay(t *mdl.Tournament, matches []MatchJson) []DayJson {
 	var days []DayJson
 	days = make([]DayJson, len(mapOfDays))
 	i := 0
-	for key, value := range mapOfDays {
-		days[i].Date, _ = time.Parse(shortForm, key)
+	for _, value := range mapOfDays {
+		days[i].Date = value[0].Day
 		days[i].Matches = value
 		i++
 	}
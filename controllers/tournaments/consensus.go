@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"appengine"
+
+	"github.com/taironas/route"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// A ConsensusJson is a variable to hold the crowd consensus of a match.
+type ConsensusJson struct {
+	MatchId       int64
+	Scope         string
+	ScopeId       int64
+	Predicts      int64
+	Win1          float64 // percentage of predicts with first team as winner.
+	Draw          float64 // percentage of predicts with a draw.
+	Win2          float64 // percentage of predicts with second team as winner.
+	AverageGoals1 float64
+	AverageGoals2 float64
+	Scorelines    []ScorelineJson
+}
+
+// A ScorelineJson is a variable to hold a predicted scoreline and its share of the predicts.
+type ScorelineJson struct {
+	Result1    int64
+	Result2    int64
+	Count      int64
+	Percentage float64
+}
+
+// Match consensus handler:
+//
+// Use this handler to get the crowd consensus of a match.
+// You can specify the scope parameter to be "global", "tournament" or "team".
+// When scope is "team" the teamId parameter is required.
+//	GET	/j/tournaments/[0-9]+/matches/[0-9]+/consensus?scope=team&teamId=[0-9]+
+//
+// The consensus is only available once the match is locked.
+func MatchConsensus(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament match consensus Handler:"
+
+	if r.Method == "GET" {
+		// get tournament id
+		strTournamentId, err := route.Context.Get(r, "tournamentId")
+		if err != nil {
+			log.Errorf(c, "%s error getting tournament id, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournamentId int64
+		tournamentId, err = strconv.ParseInt(strTournamentId, 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting tournament id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournament *mdl.Tournament
+		tournament, err = mdl.TournamentById(c, tournamentId)
+		if err != nil {
+			log.Errorf(c, "%s tournament with id:%v was not found %v", desc, tournamentId, err)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		// get match id number
+		strmatchIdNumber, err2 := route.Context.Get(r, "matchId")
+		if err2 != nil {
+			log.Errorf(c, "%s error getting match id, err:%v", desc, err2)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchNotFound)}
+		}
+
+		var matchIdNumber int64
+		matchIdNumber, err2 = strconv.ParseInt(strmatchIdNumber, 0, 64)
+		if err2 != nil {
+			log.Errorf(c, "%s error converting match id from string to int64, err:%v", desc, err2)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchNotFound)}
+		}
+
+		match := mdl.GetMatchByIdNumber(c, *tournament, matchIdNumber)
+		if match == nil {
+			log.Errorf(c, "%s unable to get match with id number :%v", desc, matchIdNumber)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeMatchNotFound)}
+		}
+
+		if !match.IsLocked() {
+			log.Infof(c, "%s match %v is not locked yet", desc, matchIdNumber)
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeMatchConsensusNotAvailable)}
+		}
+
+		scope := r.FormValue("scope")
+		var scopeId int64
+		switch scope {
+		case mdl.ConsensusScopeTournament:
+			scopeId = tournament.Id
+		case mdl.ConsensusScopeTeam:
+			if scopeId, err = strconv.ParseInt(r.FormValue("teamId"), 0, 64); err != nil {
+				log.Errorf(c, "%s error converting team id from string to int64, err:%v", desc, err)
+				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTeamNotFound)}
+			}
+			if ok, _ := tournament.ContainsTeamId(scopeId); !ok {
+				log.Errorf(c, "%s team %v is not participating in tournament %v", desc, scopeId, tournament.Id)
+				return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTeamNotFound)}
+			}
+		default:
+			// if wrong data, we set scope to "global"
+			scope = mdl.ConsensusScopeGlobal
+		}
+
+		consensus := mdl.FindConsensus(c, match.Id, scope, scopeId)
+		if consensus == nil {
+			// consensus not computed yet, build it from the predicts.
+			log.Infof(c, "%s consensus not found, building it", desc)
+			if consensus = tournament.BuildConsensus(c, match, scope, scopeId); consensus == nil {
+				return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeInternal)}
+			}
+		}
+
+		return templateshlp.RenderJson(w, c, buildConsensusJson(match, consensus))
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// From a match and a consensus entity return a ConsensusJson data structure.
+func buildConsensusJson(m *mdl.Tmatch, cs *mdl.Consensus) ConsensusJson {
+	var csJson ConsensusJson
+	csJson.MatchId = m.IdNumber
+	csJson.Scope = cs.Scope
+	csJson.ScopeId = cs.ScopeId
+	csJson.Predicts = cs.Predicts
+	csJson.Win1, csJson.Draw, csJson.Win2 = cs.Percentages()
+	csJson.AverageGoals1, csJson.AverageGoals2 = cs.AverageGoals()
+	csJson.Scorelines = make([]ScorelineJson, len(cs.Scorelines))
+	for i, s := range cs.Scorelines {
+		csJson.Scorelines[i] = ScorelineJson{s.Result1, s.Result2, s.Count, cs.ScorelinePercentage(s)}
+	}
+	return csJson
+}
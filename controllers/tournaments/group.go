@@ -39,14 +39,22 @@ type GroupJson struct {
 	Teams []TeamJson
 }
 
-// A TeamJson is a variable to hold the basic information of a Team:
-// The name of the team, the number of points recorded in the group phase, the goals for and against.
+// A TeamJson is a variable to hold the standing of a Team in its group:
+// The name of the team, the number of points recorded in the group phase, the goals for and against,
+// the matches played, won, drawn and lost, the last results and the qualification status.
 type TeamJson struct {
-	Name   string
-	Points int64
-	GoalsF int64
-	GoalsA int64
-	Iso    string
+	Name     string
+	Points   int64
+	GoalsF   int64
+	GoalsA   int64
+	Iso      string
+	Played   int64
+	Won      int64
+	Drawn    int64
+	Lost     int64
+	GoalDiff int64
+	Form     []string // last five results, the most recent first: W, D or L.
+	Status   string   // qualified, eliminated or contention.
 }
 
 // json tournament groups handler
@@ -78,7 +86,7 @@ func Groups(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 		}
 
 		groups := mdl.Groups(c, tournament.GroupIds)
-		groupsJson := formatGroupsJson(groups)
+		groupsJson := formatGroupsJson(c, groups)
 
 		data := struct {
 			Groups []GroupJson
@@ -92,18 +100,27 @@ func Groups(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 }
 
 // Format a TGroup array into a GroupJson array.
-func formatGroupsJson(groups []*mdl.Tgroup) []GroupJson {
+// The teams of each group are sorted by rank, their standings are computed from the results of the group matches.
+func formatGroupsJson(c appengine.Context, groups []*mdl.Tgroup) []GroupJson {
 
 	groupsJson := make([]GroupJson, len(groups))
 	for i, g := range groups {
 		groupsJson[i].Name = g.Name
-		teams := make([]TeamJson, len(g.Teams))
-		for j, t := range g.Teams {
-			teams[j].Name = t.Name
-			teams[j].Points = g.Points[j]
-			teams[j].GoalsF = g.GoalsF[j]
-			teams[j].GoalsA = g.GoalsA[j]
-			teams[j].Iso = t.Iso
+		standings := g.Standings(c)
+		teams := make([]TeamJson, len(standings))
+		for j, s := range standings {
+			teams[j].Name = s.Team.Name
+			teams[j].Points = s.Points
+			teams[j].GoalsF = s.GoalsF
+			teams[j].GoalsA = s.GoalsA
+			teams[j].Iso = s.Team.Iso
+			teams[j].Played = s.Played
+			teams[j].Won = s.Won
+			teams[j].Drawn = s.Drawn
+			teams[j].Lost = s.Lost
+			teams[j].GoalDiff = s.GoalDiff
+			teams[j].Form = s.Form
+			teams[j].Status = s.Status
 		}
 		groupsJson[i].Teams = teams
 	}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"appengine"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/ical"
+	"github.com/santiaago/gonawin/helpers/log"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// Tournament iCalendar handler:
+//
+// Use this handler to get the matches of a tournament in iCalendar format.
+//	GET	/j/tournaments/[0-9]+/calendar.ics?token=[0-9a-f]+
+//
+// Calendar apps cannot send the Authorization header, the user is found with the secret token of his calendar feeds.
+// Only the participants of the tournament can get its calendar.
+func CalendarICS(w http.ResponseWriter, r *http.Request) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament iCalendar Handler:"
+
+	if r.Method == "GET" {
+		u := mdl.UserByCalendarToken(c, r.FormValue("token"))
+		if u == nil {
+			log.Errorf(c, "%s invalid calendar token", desc)
+			return &helpers.Unauthorized{Err: errors.New(helpers.ErrorCodeCalendarTokenInvalid)}
+		}
+
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+		if !tournament.Joined(c, u) {
+			log.Errorf(c, "%s user %v is not a participant of tournament %v", desc, u.Id, tournament.Id)
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeCalendarForbiden)}
+		}
+
+		cal := ical.Calendar{Name: tournament.Name, Events: tournament.CalendarEvents(c)}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		return cal.Write(w, time.Now())
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"appengine"
+
+	"github.com/taironas/route"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// A MarketJson is a variable to hold a side market switched on in a tournament.
+type MarketJson struct {
+	Market string
+	Points int64
+}
+
+// Side predict handler:
+//
+// Use this handler to get or set the side predicts of the current user for a match.
+//	GET	/j/tournaments/[0-9]+/matches/[0-9]+/sidepredict
+//	POST	/j/tournaments/[0-9]+/matches/[0-9]+/sidepredict?market=overunder&pick=1
+//
+// Side markets are switched on by the tournament admins: overunder, btts, margin and firstscorer.
+// A side predict can be set until the match is locked.
+func SidePredict(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Side Predict Handler:"
+
+	// get tournament id
+	strTournamentId, err := route.Context.Get(r, "tournamentId")
+	if err != nil {
+		log.Errorf(c, "%s error getting tournament id, err:%v", desc, err)
+		return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+	}
+
+	var tournamentId int64
+	tournamentId, err = strconv.ParseInt(strTournamentId, 0, 64)
+	if err != nil {
+		log.Errorf(c, "%s error converting tournament id from string to int64, err:%v", desc, err)
+		return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+	}
+
+	var tournament *mdl.Tournament
+	tournament, err = mdl.TournamentById(c, tournamentId)
+	if err != nil {
+		log.Errorf(c, "%s tournament with id:%v was not found %v", desc, tournamentId, err)
+		return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+	}
+
+	// get match id number
+	strmatchIdNumber, err2 := route.Context.Get(r, "matchId")
+	if err2 != nil {
+		log.Errorf(c, "%s error getting match id, err:%v", desc, err2)
+		return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchNotFoundCannotSetPrediction)}
+	}
+
+	var matchIdNumber int64
+	matchIdNumber, err2 = strconv.ParseInt(strmatchIdNumber, 0, 64)
+	if err2 != nil {
+		log.Errorf(c, "%s error converting match id from string to int64, err:%v", desc, err2)
+		return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchNotFoundCannotSetPrediction)}
+	}
+
+	match := mdl.GetMatchByIdNumber(c, *tournament, matchIdNumber)
+	if match == nil {
+		log.Errorf(c, "%s unable to get match with id number :%v", desc, matchIdNumber)
+		return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeMatchNotFoundCannotSetPrediction)}
+	}
+
+	predicts := mdl.SidePredictsByUserMatch(c, u.Id, match.Id)
+
+	if r.Method == "GET" {
+		markets := make([]MarketJson, len(tournament.Markets))
+		for i, m := range tournament.Markets {
+			markets[i] = MarketJson{m, mdl.MarketPoints(m)}
+		}
+
+		data := struct {
+			Markets      []MarketJson
+			GoalsLine    float64
+			SidePredicts []*mdl.SidePredict
+		}{
+			markets,
+			tournament.OverUnderLine(),
+			predicts,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	} else if r.Method == "POST" {
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkNotSandbox(c, tournament, desc); err != nil {
+			return err
+		}
+
+		// check if user joined the tournament
+		if !tournament.Joined(c, u) {
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotAllowedToSetPrediction)}
+		}
+
+		if !match.Ready || match.IsLocked() {
+			log.Errorf(c, "%s match with id number :%v is locked", desc, matchIdNumber)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchLockedCannotSetPrediction)}
+		}
+
+		market := r.FormValue("market")
+		if !tournament.IsMarketEnabled(market) {
+			log.Errorf(c, "%s market %v is not enabled", desc, market)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMarketNotEnabled)}
+		}
+
+		var pick int64
+		if pick, err = strconv.ParseInt(r.FormValue("pick"), 0, 64); err != nil || !mdl.IsPickValid(market, pick) {
+			log.Errorf(c, "%s invalid pick for market %v: %v", desc, market, r.FormValue("pick"))
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMarketInvalidPick)}
+		}
+
+		var predict *mdl.SidePredict
+		for _, s := range predicts {
+			if s.Market == market {
+				predict = s
+				break
+			}
+		}
+
+		if predict == nil {
+			if predict, err = mdl.CreateSidePredict(c, u.Id, match.Id, market, pick); err != nil {
+				log.Errorf(c, "%s unable to create side predict: %v", desc, err)
+				return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeCannotSetPrediction)}
+			}
+		} else {
+			predict.Pick = pick
+			predict.Updated = time.Now()
+			if err = predict.Update(c); err != nil {
+				log.Errorf(c, "%s unable to update side predict: %v", desc, err)
+				return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeCannotSetPrediction)}
+			}
+		}
+
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			SidePredict *mdl.SidePredict
+		}{
+			"Your side prediction is now set.",
+			predict,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
@@ -39,12 +39,16 @@ import (
 type MatchJson struct {
 	Id         int64
 	IdNumber   int64
-	Date       time.Time
+	Date       time.Time // kickoff in UTC.
+	LocalDate  time.Time // kickoff in the timezone of the user.
+	Timezone   string    // timezone of the user.
+	Day        time.Time // calendar day of the match at its venue.
 	Team1      string
 	Team2      string
 	Iso1       string
 	Iso2       string
 	Location   string
+	VenueId    int64
 	Result1    int64
 	Result2    int64
 	HasPredict bool
@@ -52,6 +56,15 @@ type MatchJson struct {
 	Finished   bool
 	Ready      bool
 	CanPredict bool
+	LockAt     time.Time // date predictions are locked.
+}
+
+// Set the kickoff of a match in UTC and in the timezone of the user, and its day at its venue.
+func (mj *MatchJson) setDate(date time.Time, loc, venueLoc *time.Location) {
+	mj.Date = date.UTC()
+	mj.LocalDate = date.In(loc)
+	mj.Timezone = loc.String()
+	mj.Day = mdl.MatchDay(date, venueLoc)
 }
 
 // Json tournament Matches handler
@@ -113,6 +126,7 @@ func Matches(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 // Update Match handler.
 // Update match of tournament with results information.
 // from parameter 'result' with format 'result1 result2' the match information is updated accordingly.
+// the optional parameter 'firstscorer' (1 or 2) holds the team that scored first.
 func UpdateMatchResult(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 	c := appengine.NewContext(r)
 	desc := "Tournament Update Match Result Handler:"
@@ -139,6 +153,10 @@ func UpdateMatchResult(w http.ResponseWriter, r *http.Request, u *mdl.User) erro
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
 		// get match id number
 		strmatchIdNumber, err2 := route.Context.Get(r, "matchId")
 		if err2 != nil {
@@ -177,6 +195,15 @@ func UpdateMatchResult(w http.ResponseWriter, r *http.Request, u *mdl.User) erro
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeMatchCannotUpdate)}
 		}
 
+		// first scorer is optional, it is used by the first scorer side market.
+		match.FirstScorer = 0
+		if strFirstScorer := r.FormValue("firstscorer"); len(strFirstScorer) > 0 && r1+r2 > 0 {
+			if match.FirstScorer, err = strconv.ParseInt(strFirstScorer, 0, 64); err != nil || match.FirstScorer < 0 || match.FirstScorer > 2 {
+				log.Errorf(c, "%s unable to get first scorer: %v", desc, strFirstScorer)
+				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchCannotUpdate)}
+			}
+		}
+
 		if err = mdl.SetResult(c, match, int64(r1), int64(r2), tournament); err != nil {
 			log.Errorf(c, "%s unable to set result for match with id:%v error: %v", desc, match.IdNumber, err)
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeMatchCannotUpdate)}
@@ -186,7 +213,8 @@ func UpdateMatchResult(w http.ResponseWriter, r *http.Request, u *mdl.User) erro
 		// return the updated match
 		var mjson MatchJson
 		mjson.IdNumber = match.IdNumber
-		mjson.Date = match.Date
+		mjson.setDate(match.Date, u.Location(), tournament.VenueLocations(c)[match.VenueId])
+		mjson.LockAt = match.LockDate()
 		rule := strings.Split(match.Rule, " ")
 
 		tb := mdl.GetTournamentBuilder(tournament)
@@ -200,6 +228,7 @@ func UpdateMatchResult(w http.ResponseWriter, r *http.Request, u *mdl.User) erro
 			mjson.Team2 = mapIdTeams[match.TeamId2]
 		}
 		mjson.Location = match.Location
+		mjson.VenueId = match.VenueId
 
 		mjson.Result1 = match.Result1
 		mjson.Result2 = match.Result2
@@ -250,6 +279,10 @@ func BlockMatchPrediction(w http.ResponseWriter, r *http.Request, u *mdl.User) e
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
 		// get match id number
 		strmatchIdNumber, err2 := route.Context.Get(r, "matchId")
 		if err2 != nil {
@@ -269,15 +302,16 @@ func BlockMatchPrediction(w http.ResponseWriter, r *http.Request, u *mdl.User) e
 			log.Errorf(c, "%s unable to get match with id number :%v", desc, matchIdNumber)
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeMatchNotFoundCannotUpdate)}
 		}
-		match.CanPredict = false
-		if err := mdl.UpdateMatch(c, match); err != nil {
+		// lock predictions, compute the crowd consensus and create automatic predicts.
+		if err := tournament.LockMatch(c, match); err != nil {
 			log.Errorf(c, "%s unable to update match with id :%v", desc, matchIdNumber)
 		}
 
 		// return the updated match
 		var mjson MatchJson
 		mjson.IdNumber = match.IdNumber
-		mjson.Date = match.Date
+		mjson.setDate(match.Date, u.Location(), tournament.VenueLocations(c)[match.VenueId])
+		mjson.LockAt = match.LockDate()
 		rule := strings.Split(match.Rule, " ")
 
 		tb := mdl.GetTournamentBuilder(tournament)
@@ -291,6 +325,7 @@ func BlockMatchPrediction(w http.ResponseWriter, r *http.Request, u *mdl.User) e
 			mjson.Team2 = mapIdTeams[match.TeamId2]
 		}
 		mjson.Location = match.Location
+		mjson.VenueId = match.VenueId
 
 		mjson.Result1 = match.Result1
 		mjson.Result2 = match.Result2
@@ -323,17 +358,21 @@ func buildFirstPhaseMatches(c appengine.Context, t *mdl.Tournament, u *mdl.User)
 	mapIdTeams := tb.MapOfIdTeams(c, t)
 	mapTeamCodes := tb.MapOfTeamCodes()
 
+	loc := u.Location()
+	venueLocs := t.VenueLocations(c)
 	matchesJson := make([]MatchJson, len(matches))
 	for i, m := range matches {
 		matchesJson[i].Id = m.Id
 		matchesJson[i].IdNumber = m.IdNumber
-		matchesJson[i].Date = m.Date
+		matchesJson[i].setDate(m.Date, loc, venueLocs[m.VenueId])
+		matchesJson[i].LockAt = m.LockDate()
 		matchesJson[i].Team1 = mapIdTeams[m.TeamId1]
 		matchesJson[i].Team2 = mapIdTeams[m.TeamId2]
 		matchesJson[i].Iso1 = mapTeamCodes[matchesJson[i].Team1]
 		matchesJson[i].Iso2 = mapTeamCodes[matchesJson[i].Team2]
 
 		matchesJson[i].Location = m.Location
+		matchesJson[i].VenueId = m.VenueId
 		matchesJson[i].Result1 = m.Result1
 		matchesJson[i].Result2 = m.Result2
 		matchesJson[i].Finished = m.Finished
@@ -363,13 +402,16 @@ func buildSecondPhaseMatches(c appengine.Context, t *mdl.Tournament, u *mdl.User
 	mapIdTeams := tb.MapOfIdTeams(c, t)
 	mapTeamCodes := tb.MapOfTeamCodes()
 
+	loc := u.Location()
+	venueLocs := t.VenueLocations(c)
 	matchesJson := make([]MatchJson, len(matches2ndPhase))
 
 	// append 2nd round to first one
 	for i, m := range matches2ndPhase {
 		matchesJson[i].Id = m.Id
 		matchesJson[i].IdNumber = m.IdNumber
-		matchesJson[i].Date = m.Date
+		matchesJson[i].setDate(m.Date, loc, venueLocs[m.VenueId])
+		matchesJson[i].LockAt = m.LockDate()
 		rule := strings.Split(m.Rule, " ")
 		if len(rule) == 2 {
 			matchesJson[i].Team1 = rule[0]
@@ -399,6 +441,7 @@ func buildSecondPhaseMatches(c appengine.Context, t *mdl.Tournament, u *mdl.User
 		}
 
 		matchesJson[i].Location = m.Location
+		matchesJson[i].VenueId = m.VenueId
 		matchesJson[i].Result1 = m.Result1
 		matchesJson[i].Result2 = m.Result2
 		matchesJson[i].Finished = m.Finished
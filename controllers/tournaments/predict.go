@@ -0,0 +1,281 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"appengine"
+
+	"github.com/taironas/route"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// Maximum number of predicts of a bulk prediction request, the matches of a World Cup.
+const maxBulkPredicts = 64
+
+// A PredictRevisionJson is a variable to hold a revision of a predict.
+type PredictRevisionJson struct {
+	Result1 int64
+	Result2 int64
+	Created time.Time
+	Late    bool // the revision was submitted after the match was locked.
+}
+
+// A PredictData is a variable to hold a predict sent in a bulk prediction request.
+type PredictData struct {
+	MatchId int64 // id number of the match in the tournament.
+	Result1 int64
+	Result2 int64
+}
+
+// A PredictResultJson is a variable to hold the outcome of a predict of a bulk prediction request.
+type PredictResultJson struct {
+	MatchId int64
+	Success bool
+	Error   string       `json:",omitempty"`
+	Predict *mdl.Predict `json:",omitempty"`
+}
+
+// Bulk predict handler:
+//
+// Use this handler to set the predicts of the current user for several matches in a single request.
+//	POST	/j/tournaments/[0-9]+/predicts
+//
+// The body of the request is a json object: {"Predicts": [{"MatchId": 1, "Result1": 2, "Result2": 0}, ...]}
+// At most 64 predicts can be sent. Each predict is validated against the lock status of its match. Valid predicts are saved with batched writes.
+// The response holds the outcome of each predict.
+func BulkPredict(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Bulk Predict Handler:"
+
+	if r.Method == "POST" {
+		// get tournament id
+		strTournamentId, err := route.Context.Get(r, "tournamentId")
+		if err != nil {
+			log.Errorf(c, "%s error getting tournament id, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournamentId int64
+		tournamentId, err = strconv.ParseInt(strTournamentId, 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting tournament id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournament *mdl.Tournament
+		tournament, err = mdl.TournamentById(c, tournamentId)
+		if err != nil {
+			log.Errorf(c, "%s tournament with id:%v was not found %v", desc, tournamentId, err)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkNotSandbox(c, tournament, desc); err != nil {
+			return err
+		}
+
+		// check if user joined the tournament
+		if !tournament.Joined(c, u) {
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotAllowedToSetPrediction)}
+		}
+
+		defer r.Body.Close()
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Errorf(c, "%s Error when reading request body err: %v", desc, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeCannotSetPrediction)}
+		}
+
+		var data struct {
+			Predicts []PredictData
+		}
+		if err = json.Unmarshal(body, &data); err != nil {
+			log.Errorf(c, "%s Error when decoding request body err: %v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeCannotSetPrediction)}
+		}
+		if len(data.Predicts) > maxBulkPredicts {
+			log.Errorf(c, "%s %d predicts sent, at most %d are allowed", desc, len(data.Predicts), maxBulkPredicts)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodePredictsTooMany)}
+		}
+
+		mapMatches := make(map[int64]*mdl.Tmatch)
+		for _, m := range mdl.GetAllMatchesFromTournament(c, tournament) {
+			mapMatches[m.IdNumber] = m
+		}
+
+		// validate each predict, only valid ones are saved.
+		results := make([]PredictResultJson, len(data.Predicts))
+		indexes := make([]int, 0)
+		matchIds := make([]int64, 0)
+		results1 := make([]int64, 0)
+		results2 := make([]int64, 0)
+		seen := make(map[int64]bool)
+		for i, pd := range data.Predicts {
+			results[i].MatchId = pd.MatchId
+			match, ok := mapMatches[pd.MatchId]
+			switch {
+			case !ok:
+				results[i].Error = helpers.ErrorCodeMatchNotFoundCannotSetPrediction
+			case seen[pd.MatchId]:
+				results[i].Error = helpers.ErrorCodePredictDuplicated
+			case !match.Ready || match.IsLocked():
+				results[i].Error = helpers.ErrorCodeMatchLockedCannotSetPrediction
+			case pd.Result1 < 0 || pd.Result2 < 0:
+				results[i].Error = helpers.ErrorCodeCannotSetPrediction
+			default:
+				indexes = append(indexes, i)
+				matchIds = append(matchIds, match.Id)
+				results1 = append(results1, pd.Result1)
+				results2 = append(results2, pd.Result2)
+			}
+			seen[pd.MatchId] = true
+		}
+
+		if len(matchIds) > 0 {
+			predicts, err := u.SetPredicts(c, matchIds, results1, results2)
+			if err != nil {
+				log.Errorf(c, "%s unable to set predicts: %v", desc, err)
+				for _, i := range indexes {
+					results[i].Error = helpers.ErrorCodeCannotSetPrediction
+				}
+			} else {
+				for j, i := range indexes {
+					results[i].Success = true
+					results[i].Predict = predicts[j]
+				}
+				// publish activity
+				verb := fmt.Sprintf("predicted %d matches of", len(predicts))
+				u.Publish(c, "predict", verb, mdl.ActivityEntity{}, tournament.Entity())
+			}
+		}
+
+		response := struct {
+			Results []PredictResultJson
+		}{
+			results,
+		}
+		return templateshlp.RenderJson(w, c, response)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Predict history handler:
+//
+// Use this handler to get the revision log of the predict of a user for a match.
+// The userId parameter is optional, by default the history of the current user is returned.
+// Only the user himself and, once the match is locked, the tournament administrators can see the history of a predict.
+//	GET	/j/tournaments/[0-9]+/matches/[0-9]+/predict/history?userId=[0-9]+
+//
+func PredictHistory(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Predict History Handler:"
+
+	if r.Method == "GET" {
+		// get tournament id
+		strTournamentId, err := route.Context.Get(r, "tournamentId")
+		if err != nil {
+			log.Errorf(c, "%s error getting tournament id, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournamentId int64
+		tournamentId, err = strconv.ParseInt(strTournamentId, 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting tournament id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		var tournament *mdl.Tournament
+		tournament, err = mdl.TournamentById(c, tournamentId)
+		if err != nil {
+			log.Errorf(c, "%s tournament with id:%v was not found %v", desc, tournamentId, err)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+		}
+
+		// get match id number
+		strmatchIdNumber, err2 := route.Context.Get(r, "matchId")
+		if err2 != nil {
+			log.Errorf(c, "%s error getting match id, err:%v", desc, err2)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchNotFound)}
+		}
+
+		var matchIdNumber int64
+		matchIdNumber, err2 = strconv.ParseInt(strmatchIdNumber, 0, 64)
+		if err2 != nil {
+			log.Errorf(c, "%s error converting match id from string to int64, err:%v", desc, err2)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchNotFound)}
+		}
+
+		match := mdl.GetMatchByIdNumber(c, *tournament, matchIdNumber)
+		if match == nil {
+			log.Errorf(c, "%s unable to get match with id number :%v", desc, matchIdNumber)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeMatchNotFound)}
+		}
+
+		userId := u.Id
+		if strUserId := r.FormValue("userId"); len(strUserId) > 0 {
+			if userId, err = strconv.ParseInt(strUserId, 0, 64); err != nil {
+				log.Errorf(c, "%s error converting user id from string to int64, err:%v", desc, err)
+				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeUserNotFound)}
+			}
+		}
+
+		// the predicts of other users stay hidden until the match is locked.
+		if userId != u.Id && (!match.IsLocked() || (!u.IsAdmin && !mdl.IsTournamentAdmin(c, tournament.Id, u.Id))) {
+			log.Errorf(c, "%s user %v is not allowed to see predict history of user %v", desc, u.Id, userId)
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodePredictHistoryForbiden)}
+		}
+
+		p := mdl.FindPredictByUserMatch(c, userId, match.Id)
+		if p == nil {
+			log.Errorf(c, "%s predict of user %v for match %v not found", desc, userId, match.Id)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodePredictNotFound)}
+		}
+
+		revisions := mdl.PredictRevisions(c, p.Id)
+		revisionsJson := make([]PredictRevisionJson, len(revisions))
+		for i, rev := range revisions {
+			revisionsJson[i] = PredictRevisionJson{rev.Result1, rev.Result2, rev.Created, rev.Created.After(match.LockDate())}
+		}
+
+		data := struct {
+			Predict   *mdl.Predict
+			Revisions []PredictRevisionJson
+		}{
+			p,
+			revisionsJson,
+		}
+
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
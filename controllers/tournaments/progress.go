@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"appengine"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// A ProgressJson is a variable to hold the progression of a tournament.
+type ProgressJson struct {
+	Finished     int64
+	Total        int64
+	Remaining    int64
+	Ratio        float64
+	CurrentPhase string
+	NextMatch    *MatchJson `json:",omitempty"`
+	Phases       []mdl.PhaseProgress
+}
+
+// From a tournament entity return the progression of the tournament, the next match is given in the timezone of the user.
+func formatProgressJson(c appengine.Context, t *mdl.Tournament, u *mdl.User) ProgressJson {
+	p := t.ProgressDetails(c)
+	pj := ProgressJson{
+		Finished:     p.Finished,
+		Total:        p.Total,
+		Remaining:    p.Remaining,
+		Ratio:        p.Ratio,
+		CurrentPhase: p.CurrentPhase,
+		Phases:       p.Phases,
+	}
+	if m := p.NextMatch; m != nil {
+		tb := mdl.GetTournamentBuilder(t)
+		mapIdTeams := tb.MapOfIdTeams(c, t)
+		mapTeamCodes := tb.MapOfTeamCodes()
+
+		mj := &MatchJson{Id: m.Id, IdNumber: m.IdNumber, Location: m.Location, VenueId: m.VenueId, Ready: m.Ready, CanPredict: m.CanPredict}
+		mj.setDate(m.Date, u.Location(), t.VenueLocations(c)[m.VenueId])
+		mj.LockAt = m.LockDate()
+		mj.Team1 = mapIdTeams[m.TeamId1]
+		mj.Team2 = mapIdTeams[m.TeamId2]
+		mj.Iso1 = mapTeamCodes[mj.Team1]
+		mj.Iso2 = mapTeamCodes[mj.Team2]
+		pj.NextMatch = mj
+	}
+	return pj
+}
@@ -0,0 +1,502 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"appengine"
+
+	"github.com/taironas/route"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// A QuestionData is a variable to hold the data of a question sent to create or update it.
+type QuestionData struct {
+	Text     string
+	Kind     string // choice or numeric.
+	Choices  []string
+	Points   int64
+	Deadline time.Time // optional for a match question, the lock date of the match is used by default.
+	MatchId  int64     // id number of the match in the tournament, 0 for a tournament question.
+	TeamId   int64     // id of the team the question is restricted to, 0 for all participants.
+}
+
+// A QuestionJson is a variable to hold a question and the answer of the current user.
+type QuestionJson struct {
+	Question *mdl.Question
+	IdNumber int64       `json:",omitempty"` // id number of the match of the question.
+	Answer   *mdl.Answer `json:",omitempty"`
+}
+
+// Questions handler:
+//
+// Use this handler to get the questions of a tournament with the answers of the current user.
+//	GET	/j/tournaments/[0-9]+/questions
+//
+// Questions of a team are only returned to the members of the team.
+func Questions(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Questions Handler:"
+
+	if r.Method == "GET" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		matchIdNumbers := make(map[int64]int64)
+		for _, m := range mdl.GetAllMatchesFromTournament(c, tournament) {
+			matchIdNumbers[m.Id] = m.IdNumber
+		}
+
+		answers := make(map[int64]*mdl.Answer)
+		for _, a := range mdl.AnswersByUser(c, u.Id) {
+			answers[a.QuestionId] = a
+		}
+
+		questionsJson := make([]QuestionJson, 0)
+		for _, q := range mdl.QuestionsByTournament(c, tournament.Id) {
+			if !q.IsVisibleBy(u) {
+				continue
+			}
+			questionsJson = append(questionsJson, QuestionJson{q, matchIdNumbers[q.MatchId], answers[q.Id]})
+		}
+
+		data := struct {
+			Questions []QuestionJson
+		}{
+			questionsJson,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// New question handler:
+//
+// Use this handler to create a question on a match or on a tournament.
+//	POST	/j/tournaments/[0-9]+/questions/new
+//
+// The body of the request is a json QuestionData object.
+// A tournament question can be created by the tournament admins, a team question by the team admins.
+// Only the points of tournament questions count in the tournament score.
+func NewQuestion(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament New Question Handler:"
+
+	if r.Method == "POST" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkNotSandbox(c, tournament, desc); err != nil {
+			return err
+		}
+
+		var data QuestionData
+		if data, err = questionDataFromRequest(c, r, desc); err != nil {
+			return err
+		}
+
+		if !canManageQuestion(c, u, tournament, data.TeamId) {
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeQuestionForbiden)}
+		}
+
+		q := &mdl.Question{TournamentId: tournament.Id, TeamId: data.TeamId, AuthorId: u.Id}
+		if err = setQuestionData(c, q, data, tournament); err != nil {
+			log.Errorf(c, "%s invalid question: %v", desc, err)
+			return &helpers.BadRequest{Err: err}
+		}
+
+		if q, err = mdl.CreateQuestion(c, q); err != nil {
+			log.Errorf(c, "%s unable to create question: %v", desc, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeQuestionCannotCreate)}
+		}
+
+		msg := "The question was correctly created!"
+		response := struct {
+			MessageInfo string `json:",omitempty"`
+			Question    *mdl.Question
+		}{
+			msg,
+			q,
+		}
+		return templateshlp.RenderJson(w, c, response)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Update question handler:
+//
+// Use this handler to update a question that is not resolved yet.
+//	POST	/j/tournaments/[0-9]+/questions/update/[0-9]+
+//
+// The body of the request is a json QuestionData object, the team of a question cannot be changed.
+func UpdateQuestion(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Update Question Handler:"
+
+	if r.Method == "POST" {
+		tournament, q, err := questionFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkNotSandbox(c, tournament, desc); err != nil {
+			return err
+		}
+
+		if !canManageQuestion(c, u, tournament, q.TeamId) {
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeQuestionForbiden)}
+		}
+		if q.Resolved {
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeQuestionAlreadyResolved)}
+		}
+
+		var data QuestionData
+		if data, err = questionDataFromRequest(c, r, desc); err != nil {
+			return err
+		}
+
+		if err = setQuestionData(c, q, data, tournament); err != nil {
+			log.Errorf(c, "%s invalid question: %v", desc, err)
+			return &helpers.BadRequest{Err: err}
+		}
+
+		if err = q.Update(c); err != nil {
+			log.Errorf(c, "%s unable to update question: %v", desc, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeQuestionCannotUpdate)}
+		}
+
+		msg := "The question was correctly updated!"
+		response := struct {
+			MessageInfo string `json:",omitempty"`
+			Question    *mdl.Question
+		}{
+			msg,
+			q,
+		}
+		return templateshlp.RenderJson(w, c, response)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Destroy question handler:
+//
+// Use this handler to delete a question that is not resolved yet, its answers are deleted as well.
+//	POST	/j/tournaments/[0-9]+/questions/destroy/[0-9]+
+func DestroyQuestion(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Destroy Question Handler:"
+
+	if r.Method == "POST" {
+		tournament, q, err := questionFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkNotSandbox(c, tournament, desc); err != nil {
+			return err
+		}
+
+		if !canManageQuestion(c, u, tournament, q.TeamId) {
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeQuestionForbiden)}
+		}
+		if q.Resolved {
+			// points were already given, the question is part of the tournament scores.
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeQuestionAlreadyResolved)}
+		}
+
+		if err = q.Destroy(c); err != nil {
+			log.Errorf(c, "%s unable to destroy question: %v", desc, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeQuestionCannotDelete)}
+		}
+
+		msg := "The question was correctly deleted!"
+		response := struct {
+			MessageInfo string `json:",omitempty"`
+		}{
+			msg,
+		}
+		return templateshlp.RenderJson(w, c, response)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Answer question handler:
+//
+// Use this handler to answer a question, the answer can be changed until the deadline of the question.
+//	POST	/j/tournaments/[0-9]+/questions/answer/[0-9]+?value=1
+//
+// value is the index of the choice for a multiple choice question, a number for a numeric question.
+func AnswerQuestion(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Answer Question Handler:"
+
+	if r.Method == "POST" {
+		tournament, q, err := questionFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkNotSandbox(c, tournament, desc); err != nil {
+			return err
+		}
+
+		if !tournament.Joined(c, u) || !q.IsVisibleBy(u) {
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeQuestionForbiden)}
+		}
+		if !q.IsOpen() {
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeQuestionClosed)}
+		}
+
+		var value int64
+		if value, err = strconv.ParseInt(r.FormValue("value"), 0, 64); err != nil || !q.IsValidAnswer(value) {
+			log.Errorf(c, "%s invalid answer: %v", desc, r.FormValue("value"))
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeQuestionInvalidAnswer)}
+		}
+
+		var answer *mdl.Answer
+		if answer = mdl.AnswerByUserQuestion(c, u.Id, q.Id); answer == nil {
+			if answer, err = mdl.CreateAnswer(c, q.Id, u.Id, value); err != nil {
+				log.Errorf(c, "%s unable to create answer: %v", desc, err)
+				return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeQuestionCannotAnswer)}
+			}
+		} else {
+			answer.Value = value
+			answer.Updated = time.Now()
+			if err = answer.Update(c); err != nil {
+				log.Errorf(c, "%s unable to update answer: %v", desc, err)
+				return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeQuestionCannotAnswer)}
+			}
+		}
+
+		msg := "Your answer is now set."
+		response := struct {
+			MessageInfo string `json:",omitempty"`
+			Answer      *mdl.Answer
+		}{
+			msg,
+			answer,
+		}
+		return templateshlp.RenderJson(w, c, response)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Resolve question handler:
+//
+// Use this handler to set the right answer of a question once its deadline has passed.
+//	POST	/j/tournaments/[0-9]+/questions/resolve/[0-9]+?value=1
+//
+// The users who gave the right answer earn the points of the question in their tournament score.
+func ResolveQuestion(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Resolve Question Handler:"
+
+	if r.Method == "POST" {
+		tournament, q, err := questionFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkNotSandbox(c, tournament, desc); err != nil {
+			return err
+		}
+
+		if !canManageQuestion(c, u, tournament, q.TeamId) {
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeQuestionForbiden)}
+		}
+		if q.Resolved {
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeQuestionAlreadyResolved)}
+		}
+		if q.IsOpen() {
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeQuestionNotClosed)}
+		}
+
+		var value int64
+		if value, err = strconv.ParseInt(r.FormValue("value"), 0, 64); err != nil || !q.IsValidAnswer(value) {
+			log.Errorf(c, "%s invalid answer: %v", desc, r.FormValue("value"))
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeQuestionInvalidAnswer)}
+		}
+
+		if err = q.Resolve(c, value); err != nil {
+			log.Errorf(c, "%s unable to resolve question: %v", desc, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeQuestionCannotUpdate)}
+		}
+
+		// publish activity
+		object := mdl.ActivityEntity{Id: q.Id, Type: "question", DisplayName: q.Text}
+		u.Publish(c, "question", "resolved question", object, tournament.Entity())
+
+		msg := "The question was correctly resolved!"
+		response := struct {
+			MessageInfo string `json:",omitempty"`
+			Question    *mdl.Question
+		}{
+			msg,
+			q,
+		}
+		return templateshlp.RenderJson(w, c, response)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Get the tournament from the tournamentId parameter of the request.
+func tournamentFromRequest(c appengine.Context, r *http.Request, desc string) (*mdl.Tournament, error) {
+	strTournamentId, err := route.Context.Get(r, "tournamentId")
+	if err != nil {
+		log.Errorf(c, "%s error getting tournament id, err:%v", desc, err)
+		return nil, &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+	}
+
+	var tournamentId int64
+	tournamentId, err = strconv.ParseInt(strTournamentId, 0, 64)
+	if err != nil {
+		log.Errorf(c, "%s error converting tournament id from string to int64, err:%v", desc, err)
+		return nil, &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+	}
+
+	var tournament *mdl.Tournament
+	if tournament, err = mdl.TournamentById(c, tournamentId); err != nil {
+		log.Errorf(c, "%s tournament with id:%v was not found %v", desc, tournamentId, err)
+		return nil, &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
+	}
+	return tournament, nil
+}
+
+// Get the tournament and the question from the tournamentId and questionId parameters of the request.
+func questionFromRequest(c appengine.Context, r *http.Request, desc string) (*mdl.Tournament, *mdl.Question, error) {
+	tournament, err := tournamentFromRequest(c, r, desc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	strQuestionId, err := route.Context.Get(r, "questionId")
+	if err != nil {
+		log.Errorf(c, "%s error getting question id, err:%v", desc, err)
+		return nil, nil, &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeQuestionNotFound)}
+	}
+
+	var questionId int64
+	if questionId, err = strconv.ParseInt(strQuestionId, 0, 64); err != nil {
+		log.Errorf(c, "%s error converting question id from string to int64, err:%v", desc, err)
+		return nil, nil, &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeQuestionNotFound)}
+	}
+
+	q, err := mdl.QuestionById(c, questionId)
+	if err != nil || q.TournamentId != tournament.Id {
+		log.Errorf(c, "%s question with id:%v was not found in tournament %v", desc, questionId, tournament.Id)
+		return nil, nil, &helpers.NotFound{Err: errors.New(helpers.ErrorCodeQuestionNotFound)}
+	}
+	return tournament, q, nil
+}
+
+// Decode the json QuestionData of the body of the request.
+func questionDataFromRequest(c appengine.Context, r *http.Request, desc string) (QuestionData, error) {
+	var data QuestionData
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Errorf(c, "%s Error when reading request body err: %v", desc, err)
+		return data, &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeQuestionInvalid)}
+	}
+	if err = json.Unmarshal(body, &data); err != nil {
+		log.Errorf(c, "%s Error when decoding request body err: %v", desc, err)
+		return data, &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeQuestionInvalid)}
+	}
+	return data, nil
+}
+
+// Validate the question data and set it in the question.
+func setQuestionData(c appengine.Context, q *mdl.Question, data QuestionData, t *mdl.Tournament) error {
+	if !helpers.IsStringValid(data.Text) || !mdl.IsQuestionKindValid(data.Kind) || data.Points <= 0 || data.Points > mdl.QuestionMaxPoints {
+		return errors.New(helpers.ErrorCodeQuestionInvalid)
+	}
+	if data.Kind == mdl.QuestionChoice && len(data.Choices) < 2 {
+		return errors.New(helpers.ErrorCodeQuestionInvalid)
+	}
+	if data.TeamId > 0 {
+		if ok, _ := t.ContainsTeamId(data.TeamId); !ok {
+			return errors.New(helpers.ErrorCodeQuestionInvalid)
+		}
+	}
+
+	deadline := data.Deadline
+	q.MatchId = 0
+	if data.MatchId > 0 {
+		match := mdl.GetMatchByIdNumber(c, *t, data.MatchId)
+		if match == nil {
+			return errors.New(helpers.ErrorCodeMatchNotFound)
+		}
+		q.MatchId = match.Id
+		if deadline.IsZero() {
+			deadline = match.LockDate()
+		}
+	}
+	if deadline.IsZero() {
+		return errors.New(helpers.ErrorCodeQuestionInvalid)
+	}
+
+	q.Text = data.Text
+	q.Kind = data.Kind
+	q.Choices = data.Choices
+	if data.Kind == mdl.QuestionNumeric {
+		q.Choices = nil
+	}
+	q.Points = data.Points
+	q.Deadline = deadline
+	return nil
+}
+
+// Check if a user can manage the questions of a tournament or of one of its teams.
+func canManageQuestion(c appengine.Context, u *mdl.User, t *mdl.Tournament, teamId int64) bool {
+	if u.IsAdmin {
+		return true
+	}
+	if teamId > 0 {
+		return mdl.IsTeamAdmin(c, teamId, u.Id)
+	}
+	return mdl.IsTournamentAdmin(c, t.Id, u.Id)
+}
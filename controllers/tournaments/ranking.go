@@ -35,7 +35,8 @@ import (
 // Tournament ranking handler:
 // Use this handler to get the ranking of a tournament.
 // The ranking is an array of users (members) or teams,
-// You can specify the rankby parameter to be "users" or "teams".
+// You can specify the rankby parameter to be "users" or "teams",
+// or "credits" to rank the members by virtual credits when the tournament is in wagering mode.
 //	GET	/j/tournament/[0-9]+/ranking/
 //
 // The response is an array of users.
@@ -68,7 +69,7 @@ func Ranking(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 
 		rankby := r.FormValue("rankby")
 		// if wrong data, we set rankby to "users"
-		if rankby != "teams" && rankby != "users" {
+		if rankby != "teams" && rankby != "users" && rankby != "credits" {
 			rankby = "users"
 		}
 
@@ -114,6 +115,38 @@ func Ranking(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 				teamsJson,
 			}
 			return templateshlp.RenderJson(w, c, data)
+		} else if rankby == "credits" {
+			log.Infof(c, "%s ready to build credits array", desc)
+			wallets := t.RankingByCredits(c, limit)
+
+			ids := make([]int64, len(wallets))
+			for i, wa := range wallets {
+				ids[i] = wa.UserId
+			}
+			users := mdl.UsersByIds(c, ids)
+
+			type credits struct {
+				Id       int64
+				Username string
+				Alias    string
+				Balance  int64
+			}
+			creditsJson := make([]credits, 0)
+			for _, wa := range wallets {
+				for _, user := range users {
+					if user.Id == wa.UserId {
+						creditsJson = append(creditsJson, credits{user.Id, user.Username, user.Alias, wa.Balance})
+						break
+					}
+				}
+			}
+
+			data := struct {
+				Credits []credits
+			}{
+				creditsJson,
+			}
+			return templateshlp.RenderJson(w, c, data)
 		}
 	}
 	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
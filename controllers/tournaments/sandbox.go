@@ -0,0 +1,246 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"appengine"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// A SandboxJson is a variable to hold the summary of a sandbox.
+type SandboxJson struct {
+	Id           int64
+	TournamentId int64 // id of the replayed tournament.
+	Name         string
+	Score        int64
+	Revealed     int
+	Created      time.Time
+}
+
+// New sandbox handler:
+//
+// Use this handler to replay a finished tournament in a practice sandbox.
+//	POST	/j/tournaments/[0-9]+/replay
+//
+// The sandbox is a private copy of the tournament whose results are revealed match by match as the user predicts.
+// A user has at most 3 sandboxes of a tournament.
+func NewSandbox(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "New sandbox handler:"
+
+	if r.Method == "POST" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		if tournament.IsSandbox() || !tournament.IsFinished(c) {
+			log.Errorf(c, "%s tournament %d cannot be replayed", desc, tournament.Id)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotReplayable)}
+		}
+
+		sandbox, err := tournament.CreateSandbox(c, u)
+		if err != nil {
+			log.Errorf(c, "%s unable to create sandbox: %v", desc, err)
+			if err.Error() == helpers.ErrorCodeSandboxLimit {
+				return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeSandboxLimit)}
+			}
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeSandboxCannotCreate)}
+		}
+
+		msg := fmt.Sprintf("You can now replay the tournament %s!", tournament.Name)
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			Sandbox     SandboxJson
+		}{
+			msg,
+			formatSandboxJson(sandbox, tournament.Name),
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Sandboxes handler:
+//
+// Use this handler to get the sandboxes of the current user, the most recent first.
+//	GET	/j/tournaments/sandboxes
+func Sandboxes(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+
+	if r.Method == "GET" {
+		sandboxes := mdl.SandboxesByUser(c, u.Id)
+		if len(sandboxes) == 0 {
+			return templateshlp.RenderEmptyJsonArray(w, c)
+		}
+
+		sj := make([]SandboxJson, 0)
+		for _, s := range sandboxes {
+			t, err := mdl.TournamentById(c, s.Id)
+			if err != nil {
+				continue
+			}
+			sj = append(sj, formatSandboxJson(s, t.Name))
+		}
+		return templateshlp.RenderJson(w, c, sj)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Sandbox handler:
+//
+// Use this handler to get a sandbox of the current user with its matches and its progression.
+//	GET	/j/tournaments/[0-9]+/sandbox
+//
+// The matches are only given a result once they are revealed, with the predict of the user.
+func Sandbox(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Sandbox handler:"
+
+	if r.Method == "GET" {
+		tournament, sandbox, err := sandboxFromRequest(c, r, u, desc)
+		if err != nil {
+			return err
+		}
+
+		matchesJson := buildMatchesFromTournament(c, tournament, u)
+		for i, m := range matchesJson {
+			matchesJson[i].CanPredict = m.Ready && !m.Finished
+			if r1, r2, ok := sandbox.Predict(m.Id); ok {
+				matchesJson[i].HasPredict = true
+				matchesJson[i].Predict = fmt.Sprintf("%v - %v", r1, r2)
+			}
+		}
+
+		data := struct {
+			Sandbox  SandboxJson
+			Progress ProgressJson
+			Phases   []PhaseJson
+		}{
+			formatSandboxJson(sandbox, tournament.Name),
+			formatProgressJson(c, tournament, u),
+			matchesGroupByPhase(tournament, matchesJson),
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Sandbox predict handler:
+//
+// Use this handler to predict a match of a sandbox and reveal its recorded result.
+//	POST	/j/tournaments/[0-9]+/sandbox/matches/[0-9]+/predict?result1=:result1&result2=:result2
+//
+// A match can be predicted once, the points earned are added to the score of the sandbox only.
+func SandboxPredict(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Sandbox predict handler:"
+
+	if r.Method == "POST" {
+		tournament, sandbox, err := sandboxFromRequest(c, r, u, desc)
+		if err != nil {
+			return err
+		}
+
+		var match *mdl.Tmatch
+		if _, match, err = matchFromRequest(c, r, desc); err != nil {
+			return err
+		}
+		if !match.Ready || match.Finished {
+			log.Errorf(c, "%s match %d cannot be predicted", desc, match.IdNumber)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchLockedCannotSetPrediction)}
+		}
+
+		var r1, r2 int64
+		if r1, err = strconv.ParseInt(r.FormValue("result1"), 0, 64); err != nil || r1 < 0 {
+			log.Errorf(c, "%s unable to get results, error: %v not number 1", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeCannotSetPrediction)}
+		}
+		if r2, err = strconv.ParseInt(r.FormValue("result2"), 0, 64); err != nil || r2 < 0 {
+			log.Errorf(c, "%s unable to get results, error: %v not number 2", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeCannotSetPrediction)}
+		}
+
+		points, err := sandbox.Play(c, tournament, match, r1, r2)
+		if err != nil {
+			log.Errorf(c, "%s unable to reveal match %d: %v", desc, match.IdNumber, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeSandboxCannotPredict)}
+		}
+
+		mapIdTeams := mdl.MapOfIdTeams(c, tournament)
+		msg := fmt.Sprintf("You predicted %s %d:%d %s, the result was %d:%d.", mapIdTeams[match.TeamId1], r1, r2, mapIdTeams[match.TeamId2], match.Result1, match.Result2)
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			Result1     int64
+			Result2     int64
+			Points      int64
+			Sandbox     SandboxJson
+		}{
+			msg,
+			match.Result1,
+			match.Result2,
+			points,
+			formatSandboxJson(sandbox, tournament.Name),
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Get the tournament and the sandbox of the current user from the tournamentId parameter of the request.
+func sandboxFromRequest(c appengine.Context, r *http.Request, u *mdl.User, desc string) (*mdl.Tournament, *mdl.Sandbox, error) {
+	tournament, err := tournamentFromRequest(c, r, desc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sandbox, err := mdl.SandboxById(c, tournament.Id)
+	if err != nil {
+		log.Errorf(c, "%s sandbox %d not found: %v", desc, tournament.Id, err)
+		return nil, nil, &helpers.NotFound{Err: errors.New(helpers.ErrorCodeSandboxNotFound)}
+	}
+	if sandbox.UserId != u.Id {
+		log.Errorf(c, "%s sandbox %d is not played by user %d", desc, sandbox.Id, u.Id)
+		return nil, nil, &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeSandboxForbiden)}
+	}
+	return tournament, sandbox, nil
+}
+
+// Check that a tournament is not a sandbox, a sandbox is only played through its sandbox predict handler.
+func checkNotSandbox(c appengine.Context, t *mdl.Tournament, desc string) error {
+	if t.IsSandbox() {
+		log.Errorf(c, "%s tournament %d is a sandbox", desc, t.Id)
+		return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeSandboxNotSupported)}
+	}
+	return nil
+}
+
+// From a sandbox entity and the name of its tournament return a SandboxJson data structure.
+func formatSandboxJson(s *mdl.Sandbox, name string) SandboxJson {
+	return SandboxJson{s.Id, s.TournamentId, name, s.Score, len(s.MatchIds), s.Created}
+}
@@ -19,9 +19,9 @@ package tournaments
 import (
 	"errors"
 	"fmt"
-	"math/rand"
 	"net/http"
 	"strconv"
+	"time"
 
 	"appengine"
 
@@ -34,7 +34,8 @@ import (
 )
 
 // Simulate the scores of a phase in a tournament.
-//    POST /j/tournaments/[0-9]+/matches/simulate?phase=:phaseName
+// The same seed gives the same scores, a new seed is drawn when it is missing.
+//    POST /j/tournaments/[0-9]+/matches/simulate?phase=:phaseName&seed=:seed
 func SimulateMatches(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 	c := appengine.NewContext(r)
 	desc := "Tournament Simulate Matches Handler:"
@@ -61,67 +62,169 @@ func SimulateMatches(w http.ResponseWriter, r *http.Request, u *mdl.User) error
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
-		phase := r.FormValue("phase")
-		allMatches := mdl.GetAllMatchesFromTournament(c, t)
-		phases := mdl.MatchesGroupByPhase(t, allMatches)
+		if err := checkNotArchived(c, t, desc); err != nil {
+			return err
+		}
+		if err := checkCanSimulate(c, t, desc); err != nil {
+			return err
+		}
+
+		seed, err := seedFromRequest(r)
+		if err != nil {
+			log.Errorf(c, "%s invalid seed: %v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeSimulationInvalidSeed)}
+		}
 
-		mapIdTeams := mdl.MapOfIdTeams(c, t)
+		phase := r.FormValue("phase")
 		phaseId := -1
-		var results1 []int64
-		var results2 []int64
-		var matches []*mdl.Tmatch
-		for i, ph := range phases {
-			if ph.Name != phase {
-				continue
-			}
-			phaseId = i
-			for _, d := range ph.Days {
-				for j, m := range d.Matches {
-					// simulate match here (call set results)
-					r1 := int64(rand.Intn(5))
-					r2 := int64(rand.Intn(5))
-					results1 = append(results1, r1)
-					results2 = append(results2, r2)
-					matches = append(matches, &d.Matches[j])
-					log.Infof(c, "Tournament Simulate Matches: Match#%v: %v - %v | %v - %v", m.Id, mapIdTeams[m.TeamId1], mapIdTeams[m.TeamId2], r1, r2)
-				}
+		for i, name := range mdl.GetTournamentBuilder(t).ArrayOfPhases() {
+			if name == phase {
+				phaseId = i
+				break
 			}
-			// phase done we and not break
-			break
 		}
-		if err = mdl.SetResults(c, matches, results1, results2, t); err != nil {
+		if phaseId < 0 {
+			log.Errorf(c, "%s phase %q not found", desc, phase)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeNotSupported)}
+		}
+
+		matches, err := t.SimulatePhase(c, phase, seed)
+		if err != nil {
 			log.Errorf(c, "Tournament Simulate Matches: unable to set result for matches error: %v", err)
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeMatchesCannotUpdate)}
 		}
+		log.Infof(c, "%s %d matches simulated", desc, len(matches))
 
-		// publish activities:
-		for i, match := range matches {
-			object := mdl.ActivityEntity{Id: match.TeamId1, Type: "tteam", DisplayName: mapIdTeams[match.TeamId1]}
-			target := mdl.ActivityEntity{Id: match.TeamId2, Type: "tteam", DisplayName: mapIdTeams[match.TeamId2]}
-			verb := ""
-			if results1[i] > results2[i] {
-				verb = fmt.Sprintf("won %d-%d against", results1[i], results2[i])
-			} else if results1[i] < results2[i] {
-				verb = fmt.Sprintf("lost %d-%d against", results1[i], results2[i])
-			} else {
-				verb = fmt.Sprintf("tied %d-%d against", results1[i], results2[i])
-			}
-			t.Publish(c, "match", verb, object, target)
+		// only return update phase
+		matchesJson := buildMatchesFromTournament(c, t, u)
+		phasesJson := matchesGroupByPhase(t, matchesJson)
+
+		data := struct {
+			Seed  int64
+			Phase PhaseJson
+		}{
+			seed,
+			phasesJson[phaseId],
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Simulate handler:
+//
+// Use this handler to rehearse a tournament by simulating all its matches, phase after phase.
+//	POST	/j/tournaments/[0-9]+/admin/simulate?seed=:seed
+//
+// The teams of each phase are set from the simulated results of the previous one.
+// The same seed gives the same tournament, a new seed is drawn when it is missing.
+func Simulate(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament simulate handler:"
+
+	if r.Method == "POST" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
 		}
 
-		if phaseId >= 0 {
-			// only return update phase
-			matchesJson := buildMatchesFromTournament(c, t, u)
-			phasesJson := matchesGroupByPhase(t, matchesJson)
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkCanSimulate(c, tournament, desc); err != nil {
+			return err
+		}
 
-			data := struct {
-				Phase PhaseJson
-			}{
-				phasesJson[phaseId],
-			}
-			return templateshlp.RenderJson(w, c, data)
+		seed, err := seedFromRequest(r)
+		if err != nil {
+			log.Errorf(c, "%s invalid seed: %v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeSimulationInvalidSeed)}
 		}
-		return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeInternal)}
+
+		matches, err := tournament.Simulate(c, seed)
+		if err != nil {
+			log.Errorf(c, "%s unable to simulate tournament %d: %v", desc, tournament.Id, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeMatchesCannotUpdate)}
+		}
+		log.Infof(c, "%s %d matches simulated", desc, len(matches))
+
+		matchesJson := buildMatchesFromTournament(c, tournament, u)
+
+		data := struct {
+			Seed   int64
+			Phases []PhaseJson
+		}{
+			seed,
+			matchesGroupByPhase(tournament, matchesJson),
+		}
+		return templateshlp.RenderJson(w, c, data)
 	}
 	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
 }
+
+// Team strength handler:
+//
+// Use this handler to set the strength rating of a team used to simulate its matches.
+//	POST	/j/tournaments/[0-9]+/admin/strength?team=:teamId&strength=:strength
+//
+// 0 is an average team, each point of difference between two teams multiplies the goals expected from the stronger one by e.
+func TeamStrength(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament team strength handler:"
+
+	if r.Method == "POST" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
+		teamId, err := strconv.ParseInt(r.FormValue("team"), 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting team id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTeamNotFound)}
+		}
+
+		strength, err := strconv.ParseFloat(r.FormValue("strength"), 64)
+		if err != nil || strength < -3 || strength > 3 {
+			log.Errorf(c, "%s invalid strength %q", desc, r.FormValue("strength"))
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTeamInvalidStrength)}
+		}
+
+		team, err := tournament.SetTeamStrength(c, teamId, strength)
+		if err != nil {
+			log.Errorf(c, "%s unable to set strength of team %d: %v", desc, teamId, err)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTeamNotFoundCannotUpdate)}
+		}
+
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			Team        *mdl.Tteam
+		}{
+			fmt.Sprintf("The strength of %s is now %v.", team.Name, team.Strength),
+			team,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Get the seed of a simulation from the seed parameter of the request, a new seed is drawn when it is missing.
+func seedFromRequest(r *http.Request) (int64, error) {
+	if len(r.FormValue("seed")) == 0 {
+		return time.Now().UnixNano(), nil
+	}
+	return strconv.ParseInt(r.FormValue("seed"), 0, 64)
+}
+
+// Check that a tournament can be simulated, a tournament with participants or predicts cannot.
+func checkCanSimulate(c appengine.Context, t *mdl.Tournament, desc string) error {
+	if !t.CanSimulate(c) {
+		log.Errorf(c, "%s tournament %d has participants or predicts", desc, t.Id)
+		return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeSimulationNotAllowed)}
+	}
+	return nil
+}
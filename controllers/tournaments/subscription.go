@@ -59,6 +59,14 @@ func Join(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if tournament.IsSandbox() {
+			log.Errorf(c, "%s tournament %d is a sandbox", desc, tournament.Id)
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeSandboxForbiden)}
+		}
+
 		if err := tournament.Join(c, u); err != nil {
 			log.Errorf(c, "%s error on Join tournament: %v", desc, err)
 			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeInternal)}
@@ -115,6 +123,10 @@ func Leave(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
 		if err := tournament.Leave(c, u); err != nil {
 			log.Errorf(c, "%s error on Leave team: %v", desc, err)
 			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeInternal)}
@@ -184,6 +196,14 @@ func JoinAsTeam(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if tournament.IsSandbox() {
+			log.Errorf(c, "%s tournament %d is a sandbox", desc, tournament.Id)
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeSandboxForbiden)}
+		}
+
 		var team *mdl.Team
 		if team, err1 = mdl.TeamById(c, teamId); err1 != nil {
 			log.Errorf(c, "%s team not found: %v", desc, err1)
@@ -259,6 +279,9 @@ func LeaveAsTeam(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			log.Errorf(c, "%s tournament with id: %v was not found %v", desc, tournamentId, err1)
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
 		var team *mdl.Team
 		if team, err1 = mdl.TeamById(c, teamId); err1 != nil {
 			log.Errorf(c, "team not found: %v", desc, err1)
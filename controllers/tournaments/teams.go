@@ -171,6 +171,11 @@ func UpdateTeam(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			log.Errorf(c, "%s tournament with id:%v was not found %v", desc, tournamentId, err)
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
+
+		if err := checkNotArchived(c, t, desc); err != nil {
+			return err
+		}
+
 		phaseName := r.FormValue("phase")
 		// if wrong data exit
 		if len(phaseName) == 0 {
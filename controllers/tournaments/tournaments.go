@@ -180,7 +180,7 @@ func Show(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 		teams := tournament.Teams(c)
 
 		// tournament
-		fieldsToKeep := []string{"Id", "Name", "Description", "AdminIds", "IsFirstStageComplete"}
+		fieldsToKeep := []string{"Id", "Name", "Description", "AdminIds", "IsFirstStageComplete", "LockPolicy", "LockOffset", "Markets", "GoalsLine", "Wagering", "InitialCredits", "VenueIds", "Archived"}
 		var tournamentJson mdl.TournamentJson
 		helpers.InitPointerStructure(tournament, &tournamentJson, fieldsToKeep)
 		// participant
@@ -191,7 +191,7 @@ func Show(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 		teamsJson := make([]mdl.TeamJson, len(teams))
 		helpers.TransformFromArrayOfPointers(&teams, &teamsJson, fieldsToKeep)
 		// progress
-		progress := tournament.Progress(c)
+		progress := formatProgressJson(c, tournament, u)
 		// formatted start and end
 		const layout = "2 January 2006"
 		start := tournament.Start.Format(layout)
@@ -206,7 +206,7 @@ func Show(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			Joined        bool
 			Participants  []mdl.UserJson
 			Teams         []mdl.TeamJson
-			Progress      float64
+			Progress      ProgressJson
 			Start         string
 			End           string
 			RemainingDays int64
@@ -248,10 +248,6 @@ func Destroy(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
-		if !mdl.IsTournamentAdmin(c, tournamentId, u.Id) {
-			log.Errorf(c, "%s user is not admin", desc)
-			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentDeleteForbiden)}
-		}
 		var tournament *mdl.Tournament
 		tournament, err = mdl.TournamentById(c, tournamentId)
 		if err != nil {
@@ -259,6 +255,21 @@ func Destroy(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
+		// a sandbox has no administrators, it is deleted by its user.
+		if tournament.IsSandbox() {
+			if sandbox, err := mdl.SandboxById(c, tournament.Id); err != nil || sandbox.UserId != u.Id {
+				log.Errorf(c, "%s user is not the user of the sandbox", desc)
+				return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeSandboxForbiden)}
+			}
+		} else if !mdl.IsTournamentAdmin(c, tournamentId, u.Id) {
+			log.Errorf(c, "%s user is not admin", desc)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeTournamentDeleteForbiden)}
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
 		// delete all tournament-user relationships
 		for _, participant := range tournament.Participants(c) {
 			if err := participant.RemoveTournamentId(c, tournament.Id); err != nil {
@@ -288,12 +299,28 @@ func Destroy(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 		if err := mdl.DestroyGroups(c, tournament.GroupIds); err != nil {
 			log.Errorf(c, "%s error when trying to destroy tournament's groups: %v", desc, err)
 		}
+		// delete venues
+		if err := mdl.DestroyVenues(c, tournament.VenueIds); err != nil {
+			log.Errorf(c, "%s error when trying to destroy tournament's venues: %v", desc, err)
+		}
+		// delete final standings
+		if err := mdl.DestroyFinalStandings(c, tournament.Id); err != nil {
+			log.Errorf(c, "%s error when trying to destroy tournament's final standings: %v", desc, err)
+		}
+		// delete sandbox
+		if tournament.IsSandbox() {
+			if err := mdl.DestroySandbox(c, tournament.Id); err != nil {
+				log.Errorf(c, "%s error when trying to destroy tournament's sandbox: %v", desc, err)
+			}
+		}
 
 		// delete the tournament
 		tournament.Destroy(c)
 
-		// publish new activity
-		u.Publish(c, "tournament", "deleted tournament", tournament.Entity(), mdl.ActivityEntity{})
+		// publish new activity, a sandbox is private.
+		if !tournament.IsSandbox() {
+			u.Publish(c, "tournament", "deleted tournament", tournament.Entity(), mdl.ActivityEntity{})
+		}
 
 		msg := fmt.Sprintf("The tournament %s has been destroyed!", tournament.Name)
 		data := struct {
@@ -340,6 +367,10 @@ func Update(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFoundCannotUpdate)}
 		}
 
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+
 		// only work on name other values should not be editable
 		defer r.Body.Close()
 		body, err := ioutil.ReadAll(r.Body)
@@ -591,12 +622,16 @@ func Reset(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			log.Errorf(c, "%s tournament with id:%v was not found %v", desc, tournamentId, err)
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
+
+		if err := checkNotArchived(c, t, desc); err != nil {
+			return err
+		}
 		if err = t.Reset(c); err != nil {
 			log.Errorf(c, "%s unable to reset tournament: %v error:", desc, tournamentId, err)
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeInternal)}
 		}
 		groups := mdl.Groups(c, t.GroupIds)
-		groupsJson := formatGroupsJson(groups)
+		groupsJson := formatGroupsJson(c, groups)
 
 		msg := fmt.Sprintf("Tournament is now reset.")
 		data := struct {
@@ -638,6 +673,13 @@ func Predict(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeTournamentNotFound)}
 		}
 
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkNotSandbox(c, tournament, desc); err != nil {
+			return err
+		}
+
 		// check if user joined the tournament
 		if !tournament.Joined(c, u) {
 			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotAllowedToSetPrediction)}
@@ -662,6 +704,11 @@ func Predict(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			log.Errorf(c, "%s unable to get match with id number :%v", desc, matchIdNumber)
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeMatchNotFoundCannotSetPrediction)}
 		}
+		if !match.Ready || match.IsLocked() {
+			log.Errorf(c, "%s match with id number :%v is locked", desc, matchIdNumber)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchLockedCannotSetPrediction)}
+		}
+
 		result1 := r.FormValue("result1")
 		result2 := r.FormValue("result2")
 		var r1, r2 int
@@ -694,9 +741,7 @@ func Predict(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 
 		} else {
 			// predict already exist so just update resulst.
-			p.Result1 = int64(r1)
-			p.Result2 = int64(r2)
-			if err := p.Update(c); err != nil {
+			if err := p.Change(c, int64(r1), int64(r2)); err != nil {
 				log.Errorf(c, "%s unable to edit predict entity. %v", desc, err)
 				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeCannotSetPrediction)}
 			}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"appengine"
+
+	"github.com/taironas/route"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// Tournament venues handler:
+//
+// Use this handler to get the venues of a tournament.
+//	GET	/j/tournaments/[0-9]+/venues
+func Venues(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Venues Handler:"
+
+	if r.Method == "GET" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		venues := mdl.Venues(c, tournament.VenueIds)
+		if venues == nil {
+			venues = []*mdl.Venue{}
+		}
+
+		data := struct {
+			Venues []*mdl.Venue
+		}{
+			venues,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Tournament venue matches handler:
+//
+// Use this handler to get the matches of a tournament that take place in a venue.
+//	GET	/j/tournaments/[0-9]+/venues/[0-9]+/matches
+func VenueMatches(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Venue Matches Handler:"
+
+	if r.Method == "GET" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		strVenueId, err := route.Context.Get(r, "venueId")
+		if err != nil {
+			log.Errorf(c, "%s error getting venue id, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeVenueNotFound)}
+		}
+
+		var venueId int64
+		venueId, err = strconv.ParseInt(strVenueId, 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting venue id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeVenueNotFound)}
+		}
+
+		found := false
+		for _, id := range tournament.VenueIds {
+			found = found || id == venueId
+		}
+		if !found {
+			log.Errorf(c, "%s venue %d is not a venue of tournament %d", desc, venueId, tournament.Id)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeVenueNotFound)}
+		}
+
+		venue, err := mdl.VenueById(c, venueId)
+		if err != nil {
+			log.Errorf(c, "%s venue not found: %v", desc, err)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeVenueNotFound)}
+		}
+
+		tb := mdl.GetTournamentBuilder(tournament)
+		mapIdTeams := tb.MapOfIdTeams(c, tournament)
+		mapTeamCodes := tb.MapOfTeamCodes()
+		predicts := mdl.Predicts(mdl.PredictsByIds(c, u.PredictIds))
+
+		loc := u.Location()
+		venueLoc := tournament.VenueLocations(c)[venue.Id]
+		matches := tournament.VenueMatches(c, venueId)
+		matchesJson := make([]MatchJson, len(matches))
+		for i, m := range matches {
+			matchesJson[i].Id = m.Id
+			matchesJson[i].IdNumber = m.IdNumber
+			matchesJson[i].setDate(m.Date, loc, venueLoc)
+			matchesJson[i].LockAt = m.LockDate()
+			matchesJson[i].Team1 = mapIdTeams[m.TeamId1]
+			matchesJson[i].Team2 = mapIdTeams[m.TeamId2]
+			matchesJson[i].Iso1 = mapTeamCodes[matchesJson[i].Team1]
+			matchesJson[i].Iso2 = mapTeamCodes[matchesJson[i].Team2]
+			matchesJson[i].Location = m.Location
+			matchesJson[i].VenueId = m.VenueId
+			matchesJson[i].Result1 = m.Result1
+			matchesJson[i].Result2 = m.Result2
+			matchesJson[i].Finished = m.Finished
+			matchesJson[i].Ready = m.Ready
+			matchesJson[i].CanPredict = m.CanPredict
+			if hasMatch, j := predicts.ContainsMatchId(m.Id); hasMatch == true {
+				matchesJson[i].HasPredict = true
+				matchesJson[i].Predict = fmt.Sprintf("%v - %v", predicts[j].Result1, predicts[j].Result2)
+			}
+		}
+
+		data := struct {
+			Venue   *mdl.Venue
+			Matches []MatchJson
+		}{
+			venue,
+			matchesJson,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package tournaments
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"appengine"
+
+	"github.com/taironas/route"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// Wallet handler:
+//
+// Use this handler to get the virtual credits, the wagers and the ledger of the current user in a tournament.
+//	GET	/j/tournaments/[0-9]+/wallet
+func Wallet(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Wallet Handler:"
+
+	if r.Method == "GET" {
+		tournament, err := tournamentFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		if !tournament.Wagering {
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeWageringNotEnabled)}
+		}
+		if !tournament.Joined(c, u) {
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotAllowedToSetPrediction)}
+		}
+
+		wallet, err := tournament.Wallet(c, u.Id)
+		if err != nil {
+			log.Errorf(c, "%s unable to get wallet of user %v: %v", desc, u.Id, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeInternal)}
+		}
+
+		data := struct {
+			Balance int64
+			Wagers  []*mdl.Wager
+			Ledger  []*mdl.LedgerEntry
+		}{
+			wallet.Balance,
+			wallet.Wagers(c),
+			wallet.Ledger(c),
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Match odds handler:
+//
+// Use this handler to get or set the odds of a match.
+//	GET	/j/tournaments/[0-9]+/matches/[0-9]+/odds
+//	POST	/j/tournaments/[0-9]+/matches/[0-9]+/odds?odds1=2.1&oddsx=3.2&odds2=3.5
+//
+// Odds are set by the tournament admins, they are derived from the predicts of the participants otherwise.
+// Derived odds only use the number of predicts of each outcome, they are neutral while there are too few predicts.
+func Odds(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Match Odds Handler:"
+
+	tournament, match, err := matchFromRequest(c, r, desc)
+	if err != nil {
+		return err
+	}
+
+	if !tournament.Wagering {
+		return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeWageringNotEnabled)}
+	}
+
+	if r.Method == "GET" {
+		data := struct {
+			Odds *mdl.MatchOdds
+		}{
+			tournament.Odds(c, match),
+		}
+		return templateshlp.RenderJson(w, c, data)
+	} else if r.Method == "POST" {
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkNotSandbox(c, tournament, desc); err != nil {
+			return err
+		}
+		if !u.IsAdmin && !mdl.IsTournamentAdmin(c, tournament.Id, u.Id) {
+			return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeMatchOddsForbiden)}
+		}
+
+		var odds [3]float64
+		for i, name := range []string{"odds1", "oddsx", "odds2"} {
+			if odds[i], err = strconv.ParseFloat(r.FormValue(name), 64); err != nil || odds[i] <= 1 {
+				log.Errorf(c, "%s invalid %s: %v", desc, name, r.FormValue(name))
+				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchOddsInvalid)}
+			}
+		}
+
+		var o *mdl.MatchOdds
+		if o, err = mdl.SetMatchOdds(c, match.Id, odds[0], odds[1], odds[2]); err != nil {
+			log.Errorf(c, "%s unable to set odds of match %v: %v", desc, match.Id, err)
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeMatchOddsCannotUpdate)}
+		}
+
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			Odds        *mdl.MatchOdds
+		}{
+			"The odds of the match were correctly updated!",
+			o,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Wager handler:
+//
+// Use this handler to stake virtual credits on the outcome of a match.
+//	POST	/j/tournaments/[0-9]+/matches/[0-9]+/wager?outcome=1&stake=100
+//
+// outcome is 1 when the first team wins, 2 when the second team wins and 0 for a draw.
+// The wager is placed at the current odds of the match and can be placed until the match is locked.
+func Wager(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Tournament Wager Handler:"
+
+	if r.Method == "POST" {
+		tournament, match, err := matchFromRequest(c, r, desc)
+		if err != nil {
+			return err
+		}
+
+		if err := checkNotArchived(c, tournament, desc); err != nil {
+			return err
+		}
+		if err := checkNotSandbox(c, tournament, desc); err != nil {
+			return err
+		}
+
+		if !tournament.Wagering {
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeWageringNotEnabled)}
+		}
+		if !tournament.Joined(c, u) {
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotAllowedToSetPrediction)}
+		}
+		if !match.Ready || match.IsLocked() {
+			log.Errorf(c, "%s match with id number :%v is locked", desc, match.IdNumber)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchLockedCannotSetPrediction)}
+		}
+
+		var outcome, stake int64
+		if outcome, err = strconv.ParseInt(r.FormValue("outcome"), 0, 64); err != nil {
+			log.Errorf(c, "%s invalid outcome: %v", desc, r.FormValue("outcome"))
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeWagerInvalid)}
+		}
+		if stake, err = strconv.ParseInt(r.FormValue("stake"), 0, 64); err != nil {
+			log.Errorf(c, "%s invalid stake: %v", desc, r.FormValue("stake"))
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeWagerInvalid)}
+		}
+
+		odds := tournament.Odds(c, match)
+
+		var wager *mdl.Wager
+		if wager, err = tournament.PlaceWager(c, u.Id, match, outcome, stake, odds.Of(outcome)); err != nil {
+			log.Errorf(c, "%s unable to place wager: %v", desc, err)
+			if err.Error() == helpers.ErrorCodeWagerInvalid || err.Error() == helpers.ErrorCodeWagerNotEnoughCredits {
+				return &helpers.BadRequest{Err: err}
+			}
+			return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeWagerCannotPlace)}
+		}
+
+		data := struct {
+			MessageInfo string `json:",omitempty"`
+			Wager       *mdl.Wager
+		}{
+			"Your wager is now placed.",
+			wager,
+		}
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// Get the tournament and the match from the tournamentId and matchId parameters of the request.
+func matchFromRequest(c appengine.Context, r *http.Request, desc string) (*mdl.Tournament, *mdl.Tmatch, error) {
+	tournament, err := tournamentFromRequest(c, r, desc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	strmatchIdNumber, err := route.Context.Get(r, "matchId")
+	if err != nil {
+		log.Errorf(c, "%s error getting match id, err:%v", desc, err)
+		return nil, nil, &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchNotFound)}
+	}
+
+	var matchIdNumber int64
+	matchIdNumber, err = strconv.ParseInt(strmatchIdNumber, 0, 64)
+	if err != nil {
+		log.Errorf(c, "%s error converting match id from string to int64, err:%v", desc, err)
+		return nil, nil, &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeMatchNotFound)}
+	}
+
+	match := mdl.GetMatchByIdNumber(c, *tournament, matchIdNumber)
+	if match == nil {
+		log.Errorf(c, "%s unable to get match with id number :%v", desc, matchIdNumber)
+		return nil, nil, &helpers.NotFound{Err: errors.New(helpers.ErrorCodeMatchNotFound)}
+	}
+	return tournament, match, nil
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package users
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"appengine"
+
+	"github.com/taironas/route"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/ical"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// User calendar token handler:
+//
+// Use this handler to get the secret token of the calendar feeds of the current user.
+//	GET	/j/users/[0-9]+/calendartoken
+//	POST	/j/users/[0-9]+/calendartoken
+//
+// The token is generated the first time, POST generates a new token and revokes the previous one.
+func CalendarToken(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "User calendar token handler:"
+
+	// get user id
+	strUserId, err := route.Context.Get(r, "userId")
+	if err != nil {
+		log.Errorf(c, "%s error getting user id, err:%v", desc, err)
+		return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeUserNotFound)}
+	}
+
+	var userId int64
+	userId, err = strconv.ParseInt(strUserId, 0, 64)
+	if err != nil {
+		log.Errorf(c, "%s error converting user id from string to int64, err:%v", desc, err)
+		return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeUserNotFound)}
+	}
+
+	if userId != u.Id {
+		log.Errorf(c, "%s error user ids do not match. url id:%v user id: %v", desc, userId, u.Id)
+		return &helpers.Forbidden{Err: errors.New(helpers.ErrorCodeUserCannotUpdate)}
+	}
+
+	var token string
+	if r.Method == "GET" {
+		token, err = u.CalendarFeedToken(c)
+	} else if r.Method == "POST" {
+		token, err = u.ResetCalendarToken(c)
+	} else {
+		return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+	}
+	if err != nil {
+		log.Errorf(c, "%s unable to get calendar token: %v", desc, err)
+		return &helpers.InternalServerError{Err: errors.New(helpers.ErrorCodeUserCannotUpdate)}
+	}
+
+	data := struct {
+		Token string
+	}{
+		token,
+	}
+	return templateshlp.RenderJson(w, c, data)
+}
+
+// User iCalendar handler:
+//
+// Use this handler to get the matches of all the tournaments of a user in iCalendar format.
+//	GET	/j/users/[0-9]+/calendar.ics?token=[0-9a-f]+
+//
+// Calendar apps cannot send the Authorization header, the user is found with the secret token of his calendar feeds.
+func CalendarICS(w http.ResponseWriter, r *http.Request) error {
+	c := appengine.NewContext(r)
+	desc := "User iCalendar handler:"
+
+	if r.Method == "GET" {
+		// get user id
+		strUserId, err := route.Context.Get(r, "userId")
+		if err != nil {
+			log.Errorf(c, "%s error getting user id, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeUserNotFound)}
+		}
+
+		var userId int64
+		userId, err = strconv.ParseInt(strUserId, 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting user id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeUserNotFound)}
+		}
+
+		u := mdl.UserByCalendarToken(c, r.FormValue("token"))
+		if u == nil || u.Id != userId {
+			log.Errorf(c, "%s invalid calendar token for user %v", desc, userId)
+			return &helpers.Unauthorized{Err: errors.New(helpers.ErrorCodeCalendarTokenInvalid)}
+		}
+
+		cal := ical.Calendar{Name: "gonawin"}
+		for _, t := range u.Tournaments(c) {
+			cal.Events = append(cal.Events, t.CalendarEvents(c)...)
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		return cal.Write(w, time.Now())
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package users
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"appengine"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// Users ranking handler:
+//
+// Use this handler to get the global ranking of users by predictor rating.
+// The rating of a user is the number of points he earned per predicted match in all the tournaments he played.
+//	GET	/j/users/ranking?season=2014&competition=worldcup&count=10&minmatches=5
+//
+// season, competition, count and minmatches parameters are optional.
+// The response is an array of users sorted by rating.
+func Ranking(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	c := appengine.NewContext(r)
+	desc := "Users Ranking Handler:"
+
+	if r.Method == "GET" {
+		var season int64
+		if strseason := r.FormValue("season"); len(strseason) > 0 {
+			var err error
+			if season, err = strconv.ParseInt(strseason, 0, 64); err != nil {
+				log.Errorf(c, "%s error during conversion of season parameter: %v", desc, err)
+				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeRankingInvalidFilter)}
+			}
+		}
+
+		competition := r.FormValue("competition")
+		if len(competition) > 0 && competition != mdl.CompetitionWorldCup && competition != mdl.CompetitionChampionsLeague {
+			log.Errorf(c, "%s unknown competition %v", desc, competition)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeRankingInvalidFilter)}
+		}
+
+		// get count parameter, if not present count is set to 10
+		count := int64(10)
+		if strcount := r.FormValue("count"); len(strcount) > 0 {
+			if n, err := strconv.ParseInt(strcount, 0, 64); err != nil {
+				log.Errorf(c, "%s error during conversion of count parameter: %v", desc, err)
+			} else if n > 0 {
+				count = n
+			}
+		}
+
+		// get minmatches parameter, if not present minmatches is set to 5
+		minMatches := int64(5)
+		if strmin := r.FormValue("minmatches"); len(strmin) > 0 {
+			if n, err := strconv.ParseInt(strmin, 0, 64); err != nil {
+				log.Errorf(c, "%s error during conversion of minmatches parameter: %v", desc, err)
+			} else if n >= 0 {
+				minMatches = n
+			}
+		}
+
+		ratings := mdl.RankingByRating(c, season, competition, minMatches, int(count))
+
+		userIds := make([]int64, len(ratings))
+		for i, ur := range ratings {
+			userIds[i] = ur.UserId
+		}
+		users := mdl.UsersByIds(c, userIds)
+		mapUsers := make(map[int64]*mdl.User)
+		for _, user := range users {
+			mapUsers[user.Id] = user
+		}
+
+		type user struct {
+			Id       int64
+			Username string
+			Alias    string
+			Matches  int64
+			Points   int64
+			Rating   float64
+		}
+		usersJson := make([]user, 0)
+		for _, ur := range ratings {
+			if usr, ok := mapUsers[ur.UserId]; ok {
+				usersJson = append(usersJson, user{usr.Id, usr.Username, usr.Alias, ur.Matches, ur.Points, ur.Rating})
+			}
+		}
+
+		data := struct {
+			Users []user
+		}{
+			usersJson,
+		}
+
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package users
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"appengine"
+
+	"github.com/taironas/route"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+	templateshlp "github.com/santiaago/gonawin/helpers/templates"
+	mdl "github.com/santiaago/gonawin/models"
+)
+
+// A TournamentRatingJson is a variable to hold the rating of a user in a tournament.
+type TournamentRatingJson struct {
+	Id      int64
+	Name    string
+	Matches int64
+	Points  int64
+	Rating  float64
+}
+
+// User stats handler:
+//
+// Use this handler to get the prediction statistics of a user.
+//	GET	/j/users/[0-9]+/stats
+//
+// The response contains the exact-hit and trend-hit rates, current and longest streaks,
+// best and worst tournaments, home and over/under biases and favourite predicted scoreline.
+func Stats(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
+	desc := "User Stats Handler:"
+	c := appengine.NewContext(r)
+
+	if r.Method == "GET" {
+		// get user id
+		strUserId, err := route.Context.Get(r, "userId")
+		if err != nil {
+			log.Errorf(c, "%s error getting user id, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeUserNotFound)}
+		}
+
+		var userId int64
+		userId, err = strconv.ParseInt(strUserId, 0, 64)
+		if err != nil {
+			log.Errorf(c, "%s error converting user id from string to int64, err:%v", desc, err)
+			return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeUserNotFound)}
+		}
+
+		var user *mdl.User
+		user, err = mdl.UserById(c, userId)
+		if err != nil {
+			log.Errorf(c, "%s user not found", desc)
+			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeUserNotFound)}
+		}
+
+		stats := mdl.StatsByUser(c, user.Id)
+		if stats == nil {
+			// no finished match predicted yet.
+			stats = &mdl.Stats{UserId: user.Id}
+		}
+
+		// best and worst tournaments by points per predicted match.
+		var best, worst *mdl.Rating
+		for _, rating := range mdl.RatingsByUser(c, user.Id) {
+			if rating.Matches == 0 {
+				continue
+			}
+			if best == nil || rating.Value() > best.Value() {
+				best = rating
+			}
+			if worst == nil || rating.Value() < worst.Value() {
+				worst = rating
+			}
+		}
+
+		data := struct {
+			UserId             int64
+			Predicts           int64
+			ExactRate          float64
+			TrendRate          float64
+			CurrentStreak      int64
+			LongestStreak      int64
+			BestTournament     *TournamentRatingJson `json:",omitempty"`
+			WorstTournament    *TournamentRatingJson `json:",omitempty"`
+			HomeBias           float64
+			OverUnderBias      float64
+			FavouriteScoreline *mdl.Scoreline `json:",omitempty"`
+		}{
+			user.Id,
+			stats.Predicts,
+			stats.ExactRate(),
+			stats.TrendRate(),
+			stats.CurrentStreak,
+			stats.LongestStreak,
+			buildTournamentRatingJson(c, best),
+			buildTournamentRatingJson(c, worst),
+			stats.HomeBias(),
+			stats.OverUnderBias(),
+			stats.FavouriteScoreline(),
+		}
+
+		return templateshlp.RenderJson(w, c, data)
+	}
+	return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeNotSupported)}
+}
+
+// From a rating entity return a TournamentRatingJson data structure.
+func buildTournamentRatingJson(c appengine.Context, rating *mdl.Rating) *TournamentRatingJson {
+	if rating == nil {
+		return nil
+	}
+	tj := &TournamentRatingJson{Id: rating.TournamentId, Matches: rating.Matches, Points: rating.Points, Rating: rating.Value()}
+	if t, err := mdl.TournamentById(c, rating.TournamentId); err == nil {
+		tj.Name = t.Name
+	}
+	return tj
+}
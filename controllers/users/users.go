@@ -25,6 +25,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"appengine"
 	"appengine/taskqueue"
@@ -44,6 +45,7 @@ type userData struct {
 		Name     string
 		Alias    string
 		Email    string
+		Timezone *string // the timezone is only changed when the field is present, empty to unset it.
 	}
 }
 
@@ -95,7 +97,7 @@ func Show(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return &helpers.NotFound{Err: errors.New(helpers.ErrorCodeUserNotFound)}
 		}
 
-		fieldsToKeep := []string{"Id", "Username", "Name", "Alias", "Email", "Created", "IsAdmin", "Auth", "TeamIds", "TournamentIds", "Score"}
+		fieldsToKeep := []string{"Id", "Username", "Name", "Alias", "Email", "Timezone", "Created", "IsAdmin", "Auth", "TeamIds", "TournamentIds", "Score"}
 		var uJson mdl.UserJson
 		helpers.InitPointerStructure(user, &uJson, fieldsToKeep)
 		log.Infof(c, "%s User: %v", desc, uJson)
@@ -200,6 +202,24 @@ func Show(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 		invitationsFieldsToKeep := []string{"Id", "Name"}
 		invitationsJson := make([]mdl.TeamJson, len(invitations))
 		helpers.TransformFromArrayOfPointers(&invitations, &invitationsJson, invitationsFieldsToKeep)
+		// badges
+		type badge struct {
+			Code         string
+			Name         string
+			Description  string
+			TournamentId int64
+			Created      time.Time
+		}
+		badges := user.Badges(c)
+		bs := make([]badge, 0)
+		for _, b := range badges {
+			rule := mdl.BadgeRuleByCode(b.Code)
+			if rule == nil {
+				log.Errorf(c, "%s badge rule %s not found", desc, b.Code)
+				continue
+			}
+			bs = append(bs, badge{b.Code, rule.Name(), rule.Description(), b.TournamentId, b.Created})
+		}
 		// imageURL
 		imageURL := helpers.UserImageURL(user.Username, user.Id)
 
@@ -211,6 +231,7 @@ func Show(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			Tournaments     []tournament          `json:",omitempty"`
 			TournamentStats []TournamentStats     `json:",omitempty"`
 			Invitations     []mdl.TeamJson        `json:",omitempty"`
+			Badges          []badge               `json:",omitempty"`
 			ImageURL        string                `json:",omitempty"`
 		}{
 			uJson,
@@ -219,6 +240,7 @@ func Show(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			tournaments2,
 			stats,
 			invitationsJson,
+			bs,
 			imageURL,
 		}
 
@@ -276,6 +298,15 @@ func Update(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			update = true
 		}
 
+		if tz := updatedData.User.Timezone; tz != nil && *tz != u.Timezone {
+			if len(*tz) > 0 && !mdl.IsTimezoneValid(*tz) {
+				log.Errorf(c, "%s invalid timezone: %v", desc, *tz)
+				return &helpers.BadRequest{Err: errors.New(helpers.ErrorCodeUserInvalidTimezone)}
+			}
+			u.Timezone = *tz
+			update = true
+		}
+
 		if update {
 			u.Update(c)
 		} else {
@@ -287,7 +318,7 @@ func Update(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 			return templateshlp.RenderJson(w, c, data)
 		}
 
-		fieldsToKeep := []string{"Id", "Username", "Name", "Alias", "Email"}
+		fieldsToKeep := []string{"Id", "Username", "Name", "Alias", "Email", "Timezone"}
 		var uJson mdl.UserJson
 		helpers.InitPointerStructure(u, &uJson, fieldsToKeep)
 
@@ -536,10 +567,15 @@ func Tournaments(w http.ResponseWriter, r *http.Request, u *mdl.User) error {
 				page = p
 			}
 		}
-		tournaments = user.TournamentsByPage(c, count, page)
+		// archived tournaments are listed apart with the archived parameter.
+		if r.FormValue("archived") == "true" {
+			tournaments = user.ArchivedTournamentsByPage(c, count, page)
+		} else {
+			tournaments = user.TournamentsByPage(c, count, page)
+		}
 
 		// tournaments
-		tournamentsFieldsToKeep := []string{"Id", "Name"}
+		tournamentsFieldsToKeep := []string{"Id", "Name", "Archived"}
 		tournamentsJson := make([]mdl.TournamentJson, len(tournaments))
 		helpers.TransformFromArrayOfPointers(&tournaments, &tournamentsJson, tournamentsFieldsToKeep)
 
@@ -59,9 +59,13 @@ func init() {
 	r.HandleFunc("/j/users/update/:userId", handlers.ErrorHandler(handlers.Authorized(usersctrl.Update)))
 	r.HandleFunc("/j/users/destroy/:userId", handlers.ErrorHandler(handlers.Authorized(usersctrl.Destroy)))
 	r.HandleFunc("/j/users/:userId/scores", handlers.ErrorHandler(handlers.Authorized(usersctrl.Score)))
+	r.HandleFunc("/j/users/:userId/stats", handlers.ErrorHandler(handlers.Authorized(usersctrl.Stats)))
 	r.HandleFunc("/j/users/search", handlers.ErrorHandler(handlers.Authorized(usersctrl.Search)))
+	r.HandleFunc("/j/users/ranking", handlers.ErrorHandler(handlers.Authorized(usersctrl.Ranking)))
 	r.HandleFunc("/j/users/:userId/teams", handlers.ErrorHandler(handlers.Authorized(usersctrl.Teams)))
 	r.HandleFunc("/j/users/:userId/tournaments", handlers.ErrorHandler(handlers.Authorized(usersctrl.Tournaments)))
+	r.HandleFunc("/j/users/:userId/calendartoken", handlers.ErrorHandler(handlers.Authorized(usersctrl.CalendarToken)))
+	r.HandleFunc("/j/users/:userId/calendar.ics", handlers.ErrorHandler(usersctrl.CalendarICS))
 	r.HandleFunc("/j/users/allow/:teamId", handlers.ErrorHandler(handlers.Authorized(usersctrl.AllowInvitation)))
 	r.HandleFunc("/j/users/deny/:teamId", handlers.ErrorHandler(handlers.Authorized(usersctrl.DenyInvitation)))
 
@@ -81,6 +85,7 @@ func init() {
 	r.HandleFunc("/j/teams/:teamId/ranking", handlers.ErrorHandler(handlers.Authorized(teamsctrl.Ranking)))
 	r.HandleFunc("/j/teams/:teamId/accuracies/:tournamentId", handlers.ErrorHandler(handlers.Authorized(teamsctrl.AccuracyByTournament)))
 	r.HandleFunc("/j/teams/:teamId/accuracies", handlers.ErrorHandler(handlers.Authorized(teamsctrl.Accuracies)))
+	r.HandleFunc("/j/teams/:teamId/raritybonus/:tournamentId", handlers.ErrorHandler(handlers.Authorized(teamsctrl.RarityBonus)))
 	r.HandleFunc("/j/teams/:teamId/prices", handlers.ErrorHandler(handlers.Authorized(teamsctrl.Prices)))
 	r.HandleFunc("/j/teams/:teamId/prices/:tournamentId", handlers.ErrorHandler(handlers.Authorized(teamsctrl.PriceByTournament)))
 	r.HandleFunc("/j/teams/:teamId/prices/update/:tournamentId", handlers.ErrorHandler(handlers.Authorized(teamsctrl.UpdatePrice)))
@@ -94,6 +99,7 @@ func init() {
 	r.HandleFunc("/j/tournaments/update/:tournamentId", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.Update)))
 	r.HandleFunc("/j/tournaments/destroy/:tournamentId", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.Destroy)))
 	r.HandleFunc("/j/tournaments/search", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Search)))
+	r.HandleFunc("/j/tournaments/sandboxes", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Sandboxes)))
 	r.HandleFunc("/j/tournaments/:tournamentId/candidates", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.CandidateTeams)))
 	r.HandleFunc("/j/tournaments/:tournamentId/participants", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Participants)))
 
@@ -119,20 +125,49 @@ func init() {
 	// tournament
 	r.HandleFunc("/j/tournaments/:tournamentId/groups", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Groups)))
 	r.HandleFunc("/j/tournaments/:tournamentId/calendar", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Calendar)))
+	r.HandleFunc("/j/tournaments/:tournamentId/archive", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Archived)))
+	r.HandleFunc("/j/tournaments/:tournamentId/replay", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.NewSandbox)))
+	r.HandleFunc("/j/tournaments/:tournamentId/sandbox", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Sandbox)))
+	r.HandleFunc("/j/tournaments/:tournamentId/sandbox/matches/:matchId/predict", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.SandboxPredict)))
+	r.HandleFunc("/j/tournaments/:tournamentId/bracket", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Bracket)))
+	r.HandleFunc("/j/tournaments/:tournamentId/calendar.ics", handlers.ErrorHandler(tournamentsctrl.CalendarICS))
+	r.HandleFunc("/j/tournaments/:tournamentId/venues", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Venues)))
+	r.HandleFunc("/j/tournaments/:tournamentId/venues/:venueId/matches", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.VenueMatches)))
 	r.HandleFunc("/j/tournaments/:tournamentId/:teamId/calendarwithprediction", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.CalendarWithPrediction)))
 	r.HandleFunc("/j/tournaments/:tournamentId/matches", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Matches)))
 	r.HandleFunc("/j/tournaments/:tournamentId/matches/:matchId/update", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.UpdateMatchResult)))
 	r.HandleFunc("/j/tournaments/:tournamentId/matches/:matchId/predict", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Predict)))
+	r.HandleFunc("/j/tournaments/:tournamentId/matches/:matchId/predict/history", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.PredictHistory)))
+	r.HandleFunc("/j/tournaments/:tournamentId/matches/:matchId/sidepredict", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.SidePredict)))
+	r.HandleFunc("/j/tournaments/:tournamentId/matches/:matchId/odds", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Odds)))
+	r.HandleFunc("/j/tournaments/:tournamentId/matches/:matchId/wager", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Wager)))
+	r.HandleFunc("/j/tournaments/:tournamentId/wallet", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Wallet)))
+	r.HandleFunc("/j/tournaments/:tournamentId/predicts", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.BulkPredict)))
+	r.HandleFunc("/j/tournaments/:tournamentId/autopredict", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.AutoPredict)))
+	r.HandleFunc("/j/tournaments/:tournamentId/questions", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Questions)))
+	r.HandleFunc("/j/tournaments/:tournamentId/questions/new", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.NewQuestion)))
+	r.HandleFunc("/j/tournaments/:tournamentId/questions/update/:questionId", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.UpdateQuestion)))
+	r.HandleFunc("/j/tournaments/:tournamentId/questions/destroy/:questionId", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.DestroyQuestion)))
+	r.HandleFunc("/j/tournaments/:tournamentId/questions/answer/:questionId", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.AnswerQuestion)))
+	r.HandleFunc("/j/tournaments/:tournamentId/questions/resolve/:questionId", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.ResolveQuestion)))
 	r.HandleFunc("/j/tournaments/:tournamentId/matches/:matchId/blockprediction", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.BlockMatchPrediction)))
+	r.HandleFunc("/j/tournaments/:tournamentId/matches/:matchId/consensus", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.MatchConsensus)))
 	r.HandleFunc("/j/tournaments/:tournamentId/ranking", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Ranking)))
 	r.HandleFunc("/j/tournaments/:tournamentId/teams", handlers.ErrorHandler(handlers.Authorized(tournamentsctrl.Teams)))
 	r.HandleFunc("/j/tournaments/:tournamentId/admin/reset", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.Reset)))
 	r.HandleFunc("/j/tournaments/:tournamentId/matches/simulate", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.SimulateMatches)))
+	r.HandleFunc("/j/tournaments/:tournamentId/admin/simulate", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.Simulate)))
+	r.HandleFunc("/j/tournaments/:tournamentId/admin/strength", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.TeamStrength)))
 	r.HandleFunc("/j/tournaments/:tournamentId/admin/updateteam", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.UpdateTeam)))
 	r.HandleFunc("/j/tournaments/:tournamentId/admin/add/:userId", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.AddAdmin)))
 	r.HandleFunc("/j/tournaments/:tournamentId/admin/remove/:userId", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.RemoveAdmin)))
 	r.HandleFunc("/j/tournaments/:tournamentId/admin/syncscores", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.SyncScores)))
 	r.HandleFunc("/j/tournaments/:tournamentId/admin/activatephase", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.ActivatePhase)))
+	r.HandleFunc("/j/tournaments/:tournamentId/admin/lockpolicy", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.LockPolicy)))
+	r.HandleFunc("/j/tournaments/:tournamentId/admin/markets", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.Markets)))
+	r.HandleFunc("/j/tournaments/:tournamentId/admin/wagering", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.Wagering)))
+	r.HandleFunc("/j/tournaments/:tournamentId/admin/clone", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.Clone)))
+	r.HandleFunc("/j/tournaments/:tournamentId/admin/archive", handlers.ErrorHandler(handlers.AdminAuthorized(tournamentsctrl.Archive)))
 
 	// activities
 	r.HandleFunc("/j/activities", handlers.ErrorHandler(handlers.Authorized(activitiesctrl.Index)))
@@ -144,8 +179,17 @@ func init() {
 	r.HandleFunc("/a/publish/users/deleteactivities", handlers.ErrorHandler(tasksctrl.DeleteUserActivities))
 	r.HandleFunc("/a/create/scoreentities", handlers.ErrorHandler(tasksctrl.CreateScoreEntities))
 	r.HandleFunc("/a/add/scoreentities/score", handlers.ErrorHandler(tasksctrl.AddScoreToScoreEntities))
+	r.HandleFunc("/a/update/users/badges", handlers.ErrorHandler(tasksctrl.UpdateUsersBadges))
+	r.HandleFunc("/a/update/users/ratings", handlers.ErrorHandler(tasksctrl.UpdateUsersRatings))
+	r.HandleFunc("/a/update/users/stats", handlers.ErrorHandler(tasksctrl.UpdateUsersStats))
 	r.HandleFunc("/a/invite", handlers.ErrorHandler(tasksctrl.Invite))
 	r.HandleFunc("/a/sync/scores/", handlers.ErrorHandler(tasksctrl.SyncScores))
+	r.HandleFunc("/a/update/consensus", handlers.ErrorHandler(tasksctrl.UpdateConsensus))
+	r.HandleFunc("/a/autopredict", handlers.ErrorHandler(tasksctrl.AutoPredict))
+	r.HandleFunc("/a/lock/matches", handlers.ErrorHandler(tasksctrl.LockMatches))
+	r.HandleFunc("/a/update/questions/scores", handlers.ErrorHandler(tasksctrl.UpdateQuestionsScores))
+	r.HandleFunc("/a/settle/wagers", handlers.ErrorHandler(tasksctrl.SettleWagers))
+	r.HandleFunc("/a/archive/tournament", handlers.ErrorHandler(tasksctrl.ArchiveTournament))
 
 	http.Handle("/", r)
 }
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+// Package ical provides a writer of calendars in the iCalendar format (RFC 5545).
+package ical
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+)
+
+// Date format of the iCalendar date-time values in UTC.
+const dateFormat = "20060102T150405Z"
+
+// Maximum length in octets of a content line, longer lines are folded.
+const maxLineLength = 75
+
+// An Event is an event of a calendar.
+type Event struct {
+	UID         string // globally unique identifier of the event.
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	Alarm       time.Time // date of the reminder of the event, no reminder if zero.
+	AlarmText   string
+}
+
+// A Calendar is a named list of events.
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Write the calendar in iCalendar format, now is the date the calendar is generated.
+func (cal *Calendar) Write(w io.Writer, now time.Time) error {
+	var b bytes.Buffer
+	stamp := now.UTC().Format(dateFormat)
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//gonawin//calendar//EN")
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	writeLine(&b, "X-WR-CALNAME:"+escape(cal.Name))
+	for _, e := range cal.Events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+e.UID)
+		writeLine(&b, "DTSTAMP:"+stamp)
+		writeLine(&b, "DTSTART:"+e.Start.UTC().Format(dateFormat))
+		writeLine(&b, "DTEND:"+e.End.UTC().Format(dateFormat))
+		writeLine(&b, "SUMMARY:"+escape(e.Summary))
+		if len(e.Description) > 0 {
+			writeLine(&b, "DESCRIPTION:"+escape(e.Description))
+		}
+		if len(e.Location) > 0 {
+			writeLine(&b, "LOCATION:"+escape(e.Location))
+		}
+		if !e.Alarm.IsZero() {
+			writeLine(&b, "BEGIN:VALARM")
+			writeLine(&b, "ACTION:DISPLAY")
+			writeLine(&b, "DESCRIPTION:"+escape(e.AlarmText))
+			writeLine(&b, "TRIGGER;VALUE=DATE-TIME:"+e.Alarm.UTC().Format(dateFormat))
+			writeLine(&b, "END:VALARM")
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+	writeLine(&b, "END:VCALENDAR")
+
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// Escape the special characters of a text value.
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// Write a content line ended by CRLF, folded every 75 octets without splitting a UTF-8 character.
+func writeLine(b *bytes.Buffer, line string) {
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > maxLineLength {
+			b.WriteString("\r\n ")
+			length = 1
+		}
+		b.WriteRune(r)
+		length += size
+	}
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	kickoff := time.Date(2014, time.June, 12, 20, 0, 0, 0, time.UTC)
+	cal := Calendar{
+		Name: "World Cup",
+		Events: []Event{
+			{
+				UID:       "1@gonawin",
+				Summary:   "Brazil - Croatia",
+				Location:  "Arena de São Paulo, São Paulo",
+				Start:     kickoff,
+				End:       kickoff.Add(2 * time.Hour),
+				Alarm:     kickoff.Add(-time.Hour),
+				AlarmText: "Predictions are locked",
+			},
+		},
+	}
+
+	var b bytes.Buffer
+	if err := cal.Write(&b, kickoff.Add(-24*time.Hour)); err != nil {
+		t.Fatalf("Write: unexpected error %v", err)
+	}
+	got := b.String()
+
+	for _, line := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:World Cup\r\n",
+		"UID:1@gonawin\r\n",
+		"DTSTAMP:20140611T200000Z\r\n",
+		"DTSTART:20140612T200000Z\r\n",
+		"DTEND:20140612T220000Z\r\n",
+		"LOCATION:Arena de São Paulo\\, São Paulo\r\n",
+		"TRIGGER;VALUE=DATE-TIME:20140612T190000Z\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("Write: %q not found in %q", line, got)
+		}
+	}
+	if strings.Contains(got, "DESCRIPTION:\r\n") {
+		t.Errorf("Write: empty description in %q", got)
+	}
+}
+
+func TestWriteLine(t *testing.T) {
+	var b bytes.Buffer
+	writeLine(&b, "SUMMARY:"+strings.Repeat("é", 40))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	if len(lines) != 2 {
+		t.Fatalf("writeLine: got %d lines wanted 2", len(lines))
+	}
+	for _, l := range lines {
+		if len(l) > maxLineLength {
+			t.Errorf("writeLine: line of %d octets", len(l))
+		}
+	}
+	if !strings.HasPrefix(lines[1], " ") {
+		t.Errorf("writeLine: folded line %q does not start with a space", lines[1])
+	}
+	if unfolded := lines[0] + lines[1][1:]; unfolded != "SUMMARY:"+strings.Repeat("é", 40) {
+		t.Errorf("writeLine: got %q after unfolding", unfolded)
+	}
+}
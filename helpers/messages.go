@@ -43,10 +43,14 @@ const (
 	ErrorCodeUserNotFoundCannotDelete          = "User not found, unable to delete"
 	ErrorCodeUserNotFoundCannotInvite          = "User not found, unable to send invitation"
 	ErrorCodeUserCannotUpdate                  = "Could not update user"
+	ErrorCodeUserInvalidTimezone               = "Timezone of user is not valid"
+	ErrorCodeCalendarTokenInvalid              = "Calendar token is not valid"
+	ErrorCodeCalendarForbiden                  = "Calendar of a tournament can only be seen by its participants"
 	ErrorCodeUsersCannotUpdate                 = "Could not update users"
 	ErrorCodeUsersCannotPublishScore           = "Could not pusblish score activities"
 	ErrorCodeUserIsTeamAdminCannotDelete       = "User cannot be deleted because he is team admin"
 	ErrorCodeUserIsTournamentAdminCannotDelete = "User cannot be deleted because he is tournament admin"
+	ErrorCodeRankingInvalidFilter              = "Ranking filter is not valid"
 	// teams
 	ErrorCodeTeamAlreadyExists        = "Sorry, that team already exists"
 	ErrorCodeTeamCannotCreate         = "Could not create the team"
@@ -75,10 +79,58 @@ const (
 	ErrorCodeMatchesCannotUpdate              = "Something went wrong, unable to update matches"
 	ErrorCodeMatchNotFoundCannotUpdate        = "Match not found, unable to update match"
 	ErrorCodeMatchNotFound                    = "Match not found"
+	ErrorCodeVenueNotFound                    = "Venue not found"
 	ErrorCodeMatchNotFoundCannotSetPrediction = "Match not found, unable to set prediction"
 	ErrorCodeCannotSetPrediction              = "Something went wrong, unable to set prediction"
 	ErrorCodeNotAllowedToSetPrediction        = "You have to join the tournament to be able to set a predict for this match"
+	ErrorCodeMatchConsensusNotAvailable       = "Crowd predictions are only available once the match is locked"
+	ErrorCodePredictNotFound                  = "Predict not found"
+	ErrorCodePredictDuplicated                = "Only one predict per match can be set"
+	ErrorCodePredictsTooMany                  = "Too many predicts in a single request"
+	ErrorCodeMatchLockedCannotSetPrediction   = "Match is locked, unable to set prediction"
+	ErrorCodeAutoPredictInvalidStrategy       = "Auto predict strategy is not valid"
+	ErrorCodeAutoPredictCannotUpdate          = "Auto predict setting cannot be updated"
+	ErrorCodeTournamentInvalidLockPolicy      = "Deadline policy of tournament is not valid"
+	ErrorCodeMarketNotEnabled                 = "Market is not enabled in this tournament"
+	ErrorCodeMarketInvalidPick                = "Pick is not valid for this market"
+	ErrorCodeTournamentInvalidMarkets         = "Markets of tournament are not valid"
+	ErrorCodeQuestionNotFound                 = "Question not found"
+	ErrorCodeQuestionInvalid                  = "Question is not valid"
+	ErrorCodeQuestionForbiden                 = "Question can only be managed by the tournament or team administrators"
+	ErrorCodeQuestionCannotCreate             = "Question cannot be created"
+	ErrorCodeQuestionCannotUpdate             = "Question cannot be updated"
+	ErrorCodeQuestionCannotDelete             = "Question cannot be deleted"
+	ErrorCodeQuestionCannotAnswer             = "Question cannot be answered"
+	ErrorCodeQuestionClosed                   = "Question does not accept answers anymore"
+	ErrorCodeQuestionNotClosed                = "Question still accepts answers"
+	ErrorCodeQuestionAlreadyResolved          = "Question is already resolved"
+	ErrorCodeQuestionInvalidAnswer            = "Answer is not valid for this question"
+	ErrorCodeWageringNotEnabled               = "Wagering is not enabled in this tournament"
+	ErrorCodeWagerInvalid                     = "Wager is not valid"
+	ErrorCodeWagerNotEnoughCredits            = "Not enough credits to place this wager"
+	ErrorCodeWagerCannotPlace                 = "Something went wrong, unable to place wager"
+	ErrorCodeMatchOddsInvalid                 = "Odds of match are not valid"
+	ErrorCodeMatchOddsForbiden                = "Odds can only be set by the tournament administrators"
+	ErrorCodeMatchOddsCannotUpdate            = "Odds of match cannot be updated"
+	ErrorCodeTournamentInvalidCredits         = "Credits of tournament are not valid"
+	ErrorCodePredictHistoryForbiden           = "Predict history can only be seen by its user and the tournament administrators"
 	ErrorCodeTeamsCannotUpdate                = "Could not update teams"
+	ErrorCodeTournamentInvalidStart           = "Start date of tournament is not valid"
+	ErrorCodeTournamentCannotClone            = "Something went wrong, unable to clone tournament"
+	ErrorCodeTournamentArchived               = "Tournament is archived, it cannot be changed anymore"
+	ErrorCodeTournamentNotArchived            = "Tournament is not archived"
+	ErrorCodeTournamentNotFinished            = "Tournament cannot be archived before all its matches are finished"
+	ErrorCodeTournamentCannotArchive          = "Something went wrong, unable to archive tournament"
+	ErrorCodeSimulationInvalidSeed            = "Seed of simulation is not valid"
+	ErrorCodeSimulationNotAllowed             = "Tournament with participants or predicts cannot be simulated"
+	ErrorCodeTeamInvalidStrength              = "Strength of team is not valid"
+	ErrorCodeTournamentNotReplayable          = "Only a finished tournament can be replayed"
+	ErrorCodeSandboxNotFound                  = "Sandbox not found"
+	ErrorCodeSandboxForbiden                  = "Sandbox can only be played by its user"
+	ErrorCodeSandboxCannotCreate              = "Something went wrong, unable to create sandbox"
+	ErrorCodeSandboxCannotPredict             = "Something went wrong, unable to reveal the match"
+	ErrorCodeSandboxNotSupported              = "Sandbox can only be played by revealing its matches"
+	ErrorCodeSandboxLimit                     = "Too many sandboxes of this tournament, delete one to replay it again"
 
 	// invite
 	ErrorCodeInviteNoEmailAddr     = "No email address has been entered"
@@ -17,6 +17,8 @@
 package models
 
 import (
+	"time"
+
 	"appengine"
 	"appengine/datastore"
 
@@ -32,23 +34,54 @@ import (
 //
 // If some participants arrive later to the tournament, previous accuracies count as 0, and this does not impact previous teams accuracy.
 type Accuracy struct {
-	Id           int64
-	TeamId       int64
-	TournamentId int64
-	Accuracies   []float64
+	Id            int64
+	TeamId        int64
+	TournamentId  int64
+	Accuracies    []float64
+	Points        []AccuracyPoint // one point per finished match, in the order the matches were finished.
+	Contributions []Contribution  // score of each team member for each finished match.
+}
+
+// AccuracyPoint holds the performance of a team for a single finished match.
+type AccuracyPoint struct {
+	MatchId  int64     // datastore match id
+	IdNumber int64     // id of match in tournament
+	Date     time.Time // date of match
+	Points   float64   // points of the match averaged over the team members
+	Accuracy float64   // accuracy of the team after the match
+	Bonus    float64   // rarity bonus of the match averaged over the team members, part of the accuracy.
+}
+
+// Contribution holds the score of a team member for a single finished match.
+type Contribution struct {
+	MatchId int64
+	UserId  int64
+	Score   int64
+	Bonus   int64 // rarity bonus of the member if the team takes it into account.
 }
 
 type AccuracyOverall struct {
 	Id           int64
 	TournamentId int64
-	Accuracy     float64       // overall accuracy
-	Progression  []Progression // progression of accuracies of team in tournament. (right now the last 5 accuracy logs)
+	Accuracy     float64          // overall accuracy
+	Progression  []Progression    // progression of accuracies of team in tournament. (right now the last 5 accuracy logs)
+	Points       []AccuracyPoint  `json:",omitempty"` // full time series of the team in tournament.
+	Members      []MemberAccuracy `json:",omitempty"` // contribution of each member to the time series.
 }
 
 type Progression struct {
 	Value float64
 }
 
+// MemberAccuracy holds the contribution of a team member to each point of the accuracy time series.
+type MemberAccuracy struct {
+	UserId   int64
+	Username string
+	Alias    string
+	Score    int64   // sum of scores of member in the matches of the time series.
+	Scores   []int64 // score of member for each point of the time series.
+}
+
 // The Json version
 type AccuracyJson struct {
 	Id           *int64     `json:",omitempty"`
@@ -65,7 +98,7 @@ func CreateAccuracy(c appengine.Context, teamId int64, tournamentId int64, oldma
 	}
 	key := datastore.NewKey(c, "Accuracy", "", aId, nil)
 	accs := make([]float64, oldmatches)
-	a := &Accuracy{aId, teamId, tournamentId, accs}
+	a := &Accuracy{aId, teamId, tournamentId, accs, make([]AccuracyPoint, 0), make([]Contribution, 0)}
 	if _, err = datastore.Put(c, key, a); err != nil {
 		return nil, err
 	}
@@ -73,17 +106,36 @@ func CreateAccuracy(c appengine.Context, teamId int64, tournamentId int64, oldma
 }
 
 // Add accuracy to array of accuracies in Accuracy entity
-func (a *Accuracy) Add(c appengine.Context, acc float64) (float64, error) {
-	// add acc with previous acc / # item + 1
+// The match, the scores and the rarity bonuses of the team members are kept to build the time series of the team.
+func (a *Accuracy) Add(c appengine.Context, acc float64, m *Tmatch, scores, bonuses map[int64]int64) (float64, error) {
 	log.Infof(c, "Accuracy add %v", acc)
+	newAcc := a.add(acc, m, scores, bonuses)
+	log.Infof(c, "Accuracy add new acc: %v", newAcc)
+	return newAcc, a.Update(c)
+}
+
+// Append the accuracy of a match, its point and the contributions of the team members, it returns the new accuracy.
+// Points stay aligned with the accuracies added since the time series exists: the last point holds the last accuracy.
+func (a *Accuracy) add(acc float64, m *Tmatch, scores, bonuses map[int64]int64) float64 {
+	// add acc with previous acc / # item + 1
 	sum := sumFloat64(&a.Accuracies)
-	log.Infof(c, "Accuracy add sum of accs: %v", a.Accuracies)
-	log.Infof(c, "Accuracy add sum of accs: %v", sum)
 	newAcc := float64(sum+acc) / float64(len(a.Accuracies)+1)
-	log.Infof(c, "Accuracy add new acc: %v", newAcc)
 	a.Accuracies = append(a.Accuracies, newAcc)
-	log.Infof(c, "Accuracy add append: %v", a.Accuracies)
-	return newAcc, a.Update(c)
+
+	sum = 0
+	bonus := float64(0)
+	for userId, score := range scores {
+		sum += float64(score)
+		bonus += float64(bonuses[userId])
+		a.Contributions = append(a.Contributions, Contribution{m.Id, userId, score, bonuses[userId]})
+	}
+	points := float64(0)
+	if len(scores) > 0 {
+		points = sum / float64(len(scores))
+		bonus = bonus / float64(len(scores))
+	}
+	a.Points = append(a.Points, AccuracyPoint{m.Id, m.IdNumber, m.Date, points, newAcc, bonus})
+	return newAcc
 }
 
 // Update a team given an id and a team pointer.
@@ -99,6 +151,46 @@ func (a *Accuracy) Update(c appengine.Context) error {
 	return nil
 }
 
+// Get the contribution of each member to the time series of the accuracy.
+// Scores are in the same order as the points of the time series, a member with no score for a match gets 0.
+func (a *Accuracy) MembersAccuracy(c appengine.Context) []MemberAccuracy {
+	userIds, scores := a.memberScores()
+
+	members := make([]MemberAccuracy, 0)
+	for _, u := range UsersByIds(c, userIds) {
+		var m MemberAccuracy
+		m.UserId = u.Id
+		m.Username = u.Username
+		m.Alias = u.Alias
+		m.Scores = scores[u.Id]
+		m.Score = sumInt64(&m.Scores)
+		members = append(members, m)
+	}
+	return members
+}
+
+// Get the scores of each member aligned with the points of the time series, and the ids of the members
+// in the order they first contributed.
+func (a *Accuracy) memberScores() ([]int64, map[int64][]int64) {
+	indexes := make(map[int64]int)
+	for i, p := range a.Points {
+		indexes[p.MatchId] = i
+	}
+
+	userIds := make([]int64, 0)
+	scores := make(map[int64][]int64)
+	for _, contrib := range a.Contributions {
+		if _, ok := scores[contrib.UserId]; !ok {
+			userIds = append(userIds, contrib.UserId)
+			scores[contrib.UserId] = make([]int64, len(a.Points))
+		}
+		if i, ok := indexes[contrib.MatchId]; ok {
+			scores[contrib.UserId][i] = contrib.Score
+		}
+	}
+	return userIds, scores
+}
+
 func sumFloat64(a *[]float64) (sum float64) {
 	for _, v := range *a {
 		sum += v
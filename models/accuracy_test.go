@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+)
+
+func TestAccuracyAdd(t *testing.T) {
+	// the team joined after 2 matches, they count as 0.
+	a := &Accuracy{Accuracies: make([]float64, 2)}
+
+	a.add(0.5, &Tmatch{Id: 10, IdNumber: 3}, map[int64]int64{1: 3, 2: 0}, map[int64]int64{1: 2})
+	a.add(1, &Tmatch{Id: 11, IdNumber: 4}, map[int64]int64{1: 3, 3: 3}, nil)
+
+	if len(a.Accuracies) != 4 || len(a.Points) != 2 {
+		t.Fatalf("add: got %d accuracies and %d points wanted 4 and 2", len(a.Accuracies), len(a.Points))
+	}
+	for i, p := range a.Points {
+		if acc := a.Accuracies[len(a.Accuracies)-len(a.Points)+i]; p.Accuracy != acc {
+			t.Errorf("point %d: got accuracy %v wanted %v", i, p.Accuracy, acc)
+		}
+	}
+	if a.Points[0].Points != 1.5 || a.Points[1].Points != 3 {
+		t.Errorf("points: got %v and %v wanted 1.5 and 3", a.Points[0].Points, a.Points[1].Points)
+	}
+	if a.Points[0].Bonus != 1 || a.Points[1].Bonus != 0 {
+		t.Errorf("bonus: got %v and %v wanted 1 and 0", a.Points[0].Bonus, a.Points[1].Bonus)
+	}
+	if len(a.Contributions) != 4 {
+		t.Errorf("contributions: got %d wanted 4", len(a.Contributions))
+	}
+
+	userIds, scores := a.memberScores()
+	if len(userIds) != 3 {
+		t.Fatalf("members: got %v wanted 3 members", userIds)
+	}
+	tests := []struct {
+		userId int64
+		want   []int64
+	}{
+		{1, []int64{3, 3}},
+		{2, []int64{0, 0}},
+		{3, []int64{0, 3}}, // member 3 did not play the first match.
+	}
+	for _, test := range tests {
+		got := scores[test.userId]
+		if len(got) != len(a.Points) || got[0] != test.want[0] || got[1] != test.want[1] {
+			t.Errorf("scores of member %d: got %v wanted %v", test.userId, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/url"
+	"time"
+
+	"appengine"
+	"appengine/datastore"
+	"appengine/taskqueue"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// Strategies used to predict a match for a user who missed the deadline.
+const (
+	AutoPredictFixed   = "fixed"   // repeat a fixed scoreline.
+	AutoPredictCrowd   = "crowd"   // follow the most predicted scoreline of the tournament.
+	AutoPredictRanking = "ranking" // favour the team with the most points in the groups.
+	AutoPredictRandom  = "random"  // seeded random result.
+)
+
+// An AutoPredict entity holds the auto predict setting of a user in a tournament.
+//
+// When a match is locked, opted-in users who did not predict it get an automatic predict
+// computed with the strategy of their setting.
+type AutoPredict struct {
+	Id           int64
+	UserId       int64
+	TournamentId int64
+	Enabled      bool
+	Strategy     string
+	Result1      int64 // scoreline of the fixed strategy, also used as fallback by the other strategies.
+	Result2      int64
+	Seed         int64 // seed of the random strategy.
+	Created      time.Time
+}
+
+// Check if a strategy is a valid auto predict strategy.
+func IsAutoPredictStrategyValid(strategy string) bool {
+	switch strategy {
+	case AutoPredictFixed, AutoPredictCrowd, AutoPredictRanking, AutoPredictRandom:
+		return true
+	}
+	return false
+}
+
+// Create an AutoPredict entity given a user, a tournament and a strategy.
+func CreateAutoPredict(c appengine.Context, userId, tournamentId int64, strategy string, result1, result2 int64) (*AutoPredict, error) {
+	aId, _, err := datastore.AllocateIDs(c, "AutoPredict", nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	key := datastore.NewKey(c, "AutoPredict", "", aId, nil)
+	now := time.Now()
+	a := &AutoPredict{aId, userId, tournamentId, true, strategy, result1, result2, now.UnixNano(), now}
+	if _, err = datastore.Put(c, key, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Get an AutoPredict key given an id.
+func AutoPredictKeyById(c appengine.Context, id int64) *datastore.Key {
+	return datastore.NewKey(c, "AutoPredict", "", id, nil)
+}
+
+// Update an AutoPredict entity.
+func (a *AutoPredict) Update(c appengine.Context) error {
+	k := AutoPredictKeyById(c, a.Id)
+	old := new(AutoPredict)
+	if err := datastore.Get(c, k, old); err == nil {
+		if _, err = datastore.Put(c, k, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search for the AutoPredict entity of a user in a tournament.
+func AutoPredictByUserTournament(c appengine.Context, userId, tournamentId int64) *AutoPredict {
+	q := datastore.NewQuery("AutoPredict").
+		Filter("UserId"+" =", userId).
+		Filter("TournamentId"+" =", tournamentId)
+
+	var autoPredicts []*AutoPredict
+	if _, err := q.GetAll(c, &autoPredicts); err != nil {
+		log.Errorf(c, "AutoPredictByUserTournament: error occurred during GetAll: %v", err)
+		return nil
+	}
+	if len(autoPredicts) == 0 {
+		return nil
+	}
+	return autoPredicts[0]
+}
+
+// Get the enabled AutoPredict entities of a tournament.
+func AutoPredictsByTournament(c appengine.Context, tournamentId int64) []*AutoPredict {
+	q := datastore.NewQuery("AutoPredict").
+		Filter("TournamentId"+" =", tournamentId).
+		Filter("Enabled"+" =", true)
+
+	var autoPredicts []*AutoPredict
+	if _, err := q.GetAll(c, &autoPredicts); err != nil {
+		log.Errorf(c, "AutoPredictsByTournament: error occurred during GetAll: %v", err)
+		return nil
+	}
+	return autoPredicts
+}
+
+// Compute the results of a match following the strategy of the auto predict.
+//
+// The crowd scoreline and the points of the teams are computed by the caller as they are shared by all users.
+func (a *AutoPredict) Results(m *Tmatch, crowd *Scoreline, points map[int64]int64) (int64, int64) {
+	switch a.Strategy {
+	case AutoPredictCrowd:
+		if crowd != nil {
+			return crowd.Result1, crowd.Result2
+		}
+	case AutoPredictRanking:
+		if points[m.TeamId1] > points[m.TeamId2] {
+			return 1, 0
+		} else if points[m.TeamId1] < points[m.TeamId2] {
+			return 0, 1
+		}
+		return 1, 1
+	case AutoPredictRandom:
+		r := rand.New(rand.NewSource(a.Seed + m.Id))
+		return int64(r.Intn(4)), int64(r.Intn(4))
+	}
+	return a.Result1, a.Result2
+}
+
+// Create automatic predicts of a match for the opted-in users of the tournament who did not predict it.
+func (t *Tournament) AutoPredict(c appengine.Context, m *Tmatch) ([]*Predict, error) {
+	desc := "Tournament.AutoPredict:"
+	predicts := make([]*Predict, 0)
+
+	autoPredicts := AutoPredictsByTournament(c, t.Id)
+	if len(autoPredicts) == 0 {
+		return predicts, nil
+	}
+
+	existing := FindPredicts(c, "MatchId", m.Id)
+	predicted := make(map[int64]bool)
+	for _, p := range existing {
+		predicted[p.UserId] = true
+	}
+
+	// most predicted scoreline of the tournament.
+	var crowd *Scoreline
+	if consensus := NewConsensus(m.Id, ConsensusScopeTournament, t.Id, predictsOfUsers(existing, t.UserIds)); len(consensus.Scorelines) > 0 {
+		crowd = &consensus.Scorelines[0]
+	}
+
+	// points of the teams in the groups of the tournament.
+	points := make(map[int64]int64)
+	for _, g := range Groups(c, t.GroupIds) {
+		for i, team := range g.Teams {
+			if i < len(g.Points) {
+				points[team.Id] += g.Points[i]
+			}
+		}
+	}
+
+	for _, a := range autoPredicts {
+		if predicted[a.UserId] {
+			continue
+		}
+		u, err := UserById(c, a.UserId)
+		if err != nil {
+			log.Errorf(c, "%s user %v not found: %v", desc, a.UserId, err)
+			continue
+		}
+		if joined, _ := u.ContainsTournamentId(t.Id); !joined {
+			continue
+		}
+		result1, result2 := a.Results(m, crowd, points)
+		p, err := CreateAutomaticPredict(c, u.Id, result1, result2, m.Id)
+		if err != nil {
+			log.Errorf(c, "%s unable to create predict of user %v: %v", desc, u.Id, err)
+			continue
+		}
+		if err = u.AddPredictId(c, p.Id); err != nil {
+			log.Errorf(c, "%s unable to add predict id to user %v: %v", desc, u.Id, err)
+		}
+		predicts = append(predicts, p)
+	}
+	return predicts, nil
+}
+
+// Send a task to create the automatic predicts of a match.
+func (t *Tournament) QueueAutoPredict(c appengine.Context, m *Tmatch) error {
+	desc := "Queue auto predict:"
+	log.Infof(c, "%s Sending to taskqueue: auto predict", desc)
+
+	b1, errm := json.Marshal(t)
+	if errm != nil {
+		log.Errorf(c, "%s Error marshaling", desc, errm)
+	}
+	b2, errm2 := json.Marshal(m)
+	if errm2 != nil {
+		log.Errorf(c, "%s Error marshaling", desc, errm2)
+	}
+
+	task := taskqueue.NewPOSTTask("/a/autopredict/", url.Values{
+		"tournament": []string{string(b1)},
+		"match":      []string{string(b2)},
+	})
+
+	if _, err := taskqueue.Add(c, task, "gw-queue"); err != nil {
+		log.Errorf(c, "%s unable to add task to taskqueue.", desc)
+		return err
+	}
+	log.Infof(c, "%s add task to taskqueue successfully", desc)
+	return nil
+}
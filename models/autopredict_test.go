@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+package models
+
+import "testing"
+
+func TestAutoPredictResults(t *testing.T) {
+	m := &Tmatch{Id: 7, TeamId1: 1, TeamId2: 2}
+	crowd := &Scoreline{2, 1, 10}
+
+	tests := []struct {
+		name    string
+		a       AutoPredict
+		crowd   *Scoreline
+		points  map[int64]int64
+		result1 int64
+		result2 int64
+	}{
+		{"fixed", AutoPredict{Strategy: AutoPredictFixed, Result1: 1, Result2: 0}, crowd, nil, 1, 0},
+		{"crowd", AutoPredict{Strategy: AutoPredictCrowd, Result1: 1, Result2: 0}, crowd, nil, 2, 1},
+		{"crowd without predicts", AutoPredict{Strategy: AutoPredictCrowd, Result1: 1, Result2: 0}, nil, nil, 1, 0},
+		{"ranking home", AutoPredict{Strategy: AutoPredictRanking}, nil, map[int64]int64{1: 6, 2: 3}, 1, 0},
+		{"ranking away", AutoPredict{Strategy: AutoPredictRanking}, nil, map[int64]int64{1: 1, 2: 4}, 0, 1},
+		{"ranking draw", AutoPredict{Strategy: AutoPredictRanking}, nil, map[int64]int64{}, 1, 1},
+		{"random", AutoPredict{Strategy: AutoPredictRandom, Seed: 42}, nil, nil, 3, 0},
+		{"unknown", AutoPredict{Strategy: "unknown", Result1: 2, Result2: 2}, crowd, nil, 2, 2},
+	}
+	for _, test := range tests {
+		result1, result2 := test.a.Results(m, test.crowd, test.points)
+		if result1 != test.result1 || result2 != test.result2 {
+			t.Errorf("Results(%q): got %d-%d wanted %d-%d", test.name, result1, result2, test.result1, test.result2)
+		}
+	}
+}
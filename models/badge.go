@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"appengine"
+	"appengine/datastore"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// A Badge entity is an achievement earned by a user.
+//
+// A badge is earned once per user, the first time its rule is fulfilled.
+type Badge struct {
+	Id           int64     // badge id
+	UserId       int64     // user id, a badge is binded to a single user.
+	Code         string    // code of the rule that awarded the badge.
+	TournamentId int64     // tournament in which the badge was earned.
+	MatchId      int64     // match that triggered the badge.
+	Created      time.Time // date the badge was earned
+}
+
+// A BadgeRule decides if a user earns a badge after a score update.
+//
+// New badges are added by implementing this interface and registering the rule with RegisterBadgeRule,
+// the scoring code does not need to be touched.
+type BadgeRule interface {
+	Code() string        // unique code of the badge.
+	Name() string        // name to display.
+	Description() string // what the user did to earn the badge.
+	Earned(c appengine.Context, u *User, t *Tournament, m *Tmatch) bool
+}
+
+// registered badge rules, in registration order.
+var badgeRules []BadgeRule
+
+// Register a badge rule so that it is evaluated after each score update.
+func RegisterBadgeRule(rule BadgeRule) {
+	for _, r := range badgeRules {
+		if r.Code() == rule.Code() {
+			panic(fmt.Sprintf("models: badge rule %s registered twice", rule.Code()))
+		}
+	}
+	badgeRules = append(badgeRules, rule)
+}
+
+// Get all registered badge rules.
+func BadgeRules() []BadgeRule {
+	return badgeRules
+}
+
+// Get a registered badge rule given a code.
+func BadgeRuleByCode(code string) BadgeRule {
+	for _, r := range badgeRules {
+		if r.Code() == code {
+			return r
+		}
+	}
+	return nil
+}
+
+// Create a Badge entity given a user id, a code, a tournament id and a match id.
+func CreateBadge(c appengine.Context, userId int64, code string, tournamentId, matchId int64) (*Badge, error) {
+	bId, _, err := datastore.AllocateIDs(c, "Badge", nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	key := datastore.NewKey(c, "Badge", "", bId, nil)
+	b := &Badge{bId, userId, code, tournamentId, matchId, time.Now()}
+	if _, err = datastore.Put(c, key, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Get a Badge given an id.
+func BadgeById(c appengine.Context, id int64) (*Badge, error) {
+	var b Badge
+	key := datastore.NewKey(c, "Badge", "", id, nil)
+
+	if err := datastore.Get(c, key, &b); err != nil {
+		log.Errorf(c, "badge not found : %v", err)
+		return &b, err
+	}
+	return &b, nil
+}
+
+// Get an array of pointers to Badge entities with respect to an array of ids.
+func BadgesByIds(c appengine.Context, ids []int64) []*Badge {
+	var badges []*Badge
+	for _, id := range ids {
+		if b, err := BadgeById(c, id); err == nil {
+			badges = append(badges, b)
+		} else {
+			log.Errorf(c, " Badge.ByIds, error occurred during ByIds call: %v", err)
+		}
+	}
+	return badges
+}
+
+// Get the badges earned by a user.
+func (u *User) Badges(c appengine.Context) []*Badge {
+	return BadgesByIds(c, u.BadgeIds)
+}
+
+// Evaluate all registered badge rules for a user after a score update.
+// Badges the user does not have yet and whose rule is fulfilled are created and published as activities.
+func (u *User) EarnBadges(c appengine.Context, t *Tournament, m *Tmatch) ([]*Badge, error) {
+	desc := "User.EarnBadges:"
+
+	earned := make(map[string]bool)
+	for _, b := range u.Badges(c) {
+		earned[b.Code] = true
+	}
+
+	badges := make([]*Badge, 0)
+	for _, rule := range badgeRules {
+		if earned[rule.Code()] || !rule.Earned(c, u, t, m) {
+			continue
+		}
+		b, err := CreateBadge(c, u.Id, rule.Code(), t.Id, m.Id)
+		if err != nil {
+			log.Errorf(c, "%s unable to create badge %s for user %v: %v", desc, rule.Code(), u.Id, err)
+			return badges, errors.New("model/badge: unable to create badge")
+		}
+
+		// publish activity, a failure does not prevent the badge from being linked to the user.
+		var activityId int64
+		verb := fmt.Sprintf("earned the badge %s", rule.Name())
+		if activity := u.BuildActivity(c, "badge", verb, ActivityEntity{}, t.Entity()); activity != nil {
+			if err := activity.save(c); err != nil {
+				log.Errorf(c, "%s unable to publish badge activity: %v", desc, err)
+			} else {
+				activityId = activity.Id
+			}
+		}
+
+		if err := u.addBadge(c, b.Id, activityId); err != nil {
+			log.Errorf(c, "%s unable to add badge %s to user %v: %v", desc, rule.Code(), u.Id, err)
+			return badges, errors.New("model/badge: unable to add badge to user")
+		}
+		badges = append(badges, b)
+	}
+	return badges, nil
+}
+
+// Add a badge and the activity that published it to a user.
+// The user is read again in a transaction so concurrent updates of the user, e.g. its score, are kept.
+func (u *User) addBadge(c appengine.Context, badgeId, activityId int64) error {
+	var user User
+	err := datastore.RunInTransaction(c, func(c appengine.Context) error {
+		key := UserKeyById(c, u.Id)
+		if err := datastore.Get(c, key, &user); err != nil {
+			return err
+		}
+		user.BadgeIds = append(user.BadgeIds, badgeId)
+		if activityId != 0 {
+			user.ActivityIds = append(user.ActivityIds, activityId)
+		}
+		_, err := datastore.Put(c, key, &user)
+		return err
+	}, nil)
+	if err != nil {
+		return err
+	}
+	*u = user
+	return nil
+}
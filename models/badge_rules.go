@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"appengine"
+)
+
+func init() {
+	RegisterBadgeRule(exactScoresInARow{5})
+	RegisterBadgeRule(groupStagePredicted{})
+	RegisterBadgeRule(perfectFinal{})
+	RegisterBadgeRule(teamLeader{7 * 24 * time.Hour})
+}
+
+// exactScoresInARow is earned when the last count predicts of a user in a tournament hit the exact score.
+type exactScoresInARow struct {
+	count int
+}
+
+func (r exactScoresInARow) Code() string { return fmt.Sprintf("exact-scores-in-a-row-%d", r.count) }
+func (r exactScoresInARow) Name() string { return fmt.Sprintf("%d in a row", r.count) }
+func (r exactScoresInARow) Description() string {
+	return fmt.Sprintf("%d exact scores in a row", r.count)
+}
+
+func (r exactScoresInARow) Earned(c appengine.Context, u *User, t *Tournament, m *Tmatch) bool {
+	score, err := u.TournamentScore(c, t)
+	if err != nil || score == nil {
+		return false
+	}
+	return exactScoresStreak(score.Scores) >= r.count
+}
+
+// groupStagePredicted is earned when a user predicted every match of the group stage of a tournament.
+type groupStagePredicted struct{}
+
+func (r groupStagePredicted) Code() string        { return "group-stage-predicted" }
+func (r groupStagePredicted) Name() string        { return "Group stage expert" }
+func (r groupStagePredicted) Description() string { return "predicted every match of the group stage" }
+
+func (r groupStagePredicted) Earned(c appengine.Context, u *User, t *Tournament, m *Tmatch) bool {
+	if len(t.Matches1stStage) == 0 {
+		return false
+	}
+	predicts := Predicts(PredictsByIds(c, u.PredictIds))
+	for _, matchId := range t.Matches1stStage {
+		if ok, _ := predicts.ContainsMatchId(matchId); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// perfectFinal is earned when a user predicts the exact score of a final.
+type perfectFinal struct{}
+
+func (r perfectFinal) Code() string        { return "perfect-final" }
+func (r perfectFinal) Name() string        { return "Perfect final" }
+func (r perfectFinal) Description() string { return "predicted the exact score of a final" }
+
+func (r perfectFinal) Earned(c appengine.Context, u *User, t *Tournament, m *Tmatch) bool {
+	limits, ok := GetTournamentBuilder(t).MapOfPhaseIntervals()[cFinals]
+	if !ok || m.IdNumber < limits[0] || m.IdNumber > limits[1] {
+		return false
+	}
+	score, err := u.ScoreForMatch(c, m)
+	return err == nil && score == 3
+}
+
+// teamLeader is earned when a user stays at the top of the ranking of one of his teams for a given duration.
+type teamLeader struct {
+	duration time.Duration
+}
+
+func (r teamLeader) Code() string        { return "team-leader" }
+func (r teamLeader) Name() string        { return "Team leader" }
+func (r teamLeader) Description() string { return "top of a team ranking for a week" }
+
+// The leader of a team is tracked when the scores of the users are updated.
+func (r teamLeader) Earned(c appengine.Context, u *User, t *Tournament, m *Tmatch) bool {
+	for _, team := range u.Teams(c) {
+		if leads(team, u.Id, time.Now(), r.duration) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if a user has been leading a team for a given duration.
+func leads(team *Team, userId int64, now time.Time, duration time.Duration) bool {
+	return team.LeaderId == userId && !team.LeaderSince.IsZero() && now.Sub(team.LeaderSince) >= duration
+}
+
+// Get the number of consecutive exact scores at the end of an array of scores.
+func exactScoresStreak(scores []int64) int {
+	streak := 0
+	for i := len(scores) - 1; i >= 0 && scores[i] == 3; i-- {
+		streak++
+	}
+	return streak
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeads(t *testing.T) {
+	now := time.Date(2014, time.July, 1, 12, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+
+	tests := []struct {
+		name string
+		team Team
+		want bool
+	}{
+		{"leader for a week", Team{LeaderId: 1, LeaderSince: now.Add(-week)}, true},
+		{"leader since yesterday", Team{LeaderId: 1, LeaderSince: now.Add(-24 * time.Hour)}, false},
+		{"other leader", Team{LeaderId: 2, LeaderSince: now.Add(-week)}, false},
+		{"leader never tracked", Team{LeaderId: 1}, false},
+	}
+	for _, test := range tests {
+		if got := leads(&test.team, 1, now, week); got != test.want {
+			t.Errorf("leads(%q): got %v wanted %v", test.name, got, test.want)
+		}
+	}
+}
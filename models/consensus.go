@@ -0,0 +1,257 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"time"
+
+	"appengine"
+	"appengine/datastore"
+	"appengine/taskqueue"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// Scopes of a crowd consensus.
+const (
+	ConsensusScopeGlobal     = "global"     // all predicts of a match.
+	ConsensusScopeTournament = "tournament" // predicts of the participants of the tournament.
+	ConsensusScopeTeam       = "team"       // predicts of the members of a team.
+)
+
+// A Consensus entity holds the crowd statistics of the predicts of a match for a given scope.
+//
+// It is computed once the match is locked so that nobody can use it to set his own predict.
+type Consensus struct {
+	Id         int64       // consensus id
+	MatchId    int64       // match id in tournament
+	Scope      string      // global, tournament or team
+	ScopeId    int64       // tournament id or team id, 0 when scope is global
+	Predicts   int64       // number of predicts taken into account
+	Scorelines []Scoreline // distribution of the predicted scorelines
+	Wins1      int64       // number of predicts with first team as winner
+	Draws      int64       // number of predicts with a draw
+	Wins2      int64       // number of predicts with second team as winner
+	Goals1     int64       // sum of predicted goals of first team
+	Goals2     int64       // sum of predicted goals of second team
+	Created    time.Time   // date of computation
+}
+
+// A Scoreline holds the number of predicts for a given result.
+type Scoreline struct {
+	Result1 int64
+	Result2 int64
+	Count   int64
+}
+
+// Build a Consensus given a match id, a scope and the predicts to take into account.
+func NewConsensus(matchId int64, scope string, scopeId int64, predicts []*Predict) *Consensus {
+	cs := &Consensus{MatchId: matchId, Scope: scope, ScopeId: scopeId, Scorelines: make([]Scoreline, 0), Created: time.Now()}
+
+	for _, p := range predicts {
+		if p == nil || p.Automatic {
+			// automatic predicts do not reflect the opinion of the crowd.
+			continue
+		}
+		cs.Predicts++
+		cs.Goals1 += p.Result1
+		cs.Goals2 += p.Result2
+		if p.Result1 > p.Result2 {
+			cs.Wins1++
+		} else if p.Result1 < p.Result2 {
+			cs.Wins2++
+		} else {
+			cs.Draws++
+		}
+
+		found := false
+		for i := range cs.Scorelines {
+			if cs.Scorelines[i].Result1 == p.Result1 && cs.Scorelines[i].Result2 == p.Result2 {
+				cs.Scorelines[i].Count++
+				found = true
+				break
+			}
+		}
+		if !found {
+			cs.Scorelines = append(cs.Scorelines, Scoreline{p.Result1, p.Result2, 1})
+		}
+	}
+	sort.Sort(ScorelineByCount(cs.Scorelines))
+	return cs
+}
+
+// Percentages of predicts with first team as winner, with a draw and with second team as winner.
+func (cs *Consensus) Percentages() (float64, float64, float64) {
+	if cs.Predicts == 0 {
+		return 0, 0, 0
+	}
+	total := float64(cs.Predicts)
+	return 100 * float64(cs.Wins1) / total, 100 * float64(cs.Draws) / total, 100 * float64(cs.Wins2) / total
+}
+
+// Average of predicted goals for first team and second team.
+func (cs *Consensus) AverageGoals() (float64, float64) {
+	if cs.Predicts == 0 {
+		return 0, 0
+	}
+	total := float64(cs.Predicts)
+	return float64(cs.Goals1) / total, float64(cs.Goals2) / total
+}
+
+// Percentage of predicts for a given scoreline.
+func (cs *Consensus) ScorelinePercentage(s Scoreline) float64 {
+	if cs.Predicts == 0 {
+		return 0
+	}
+	return 100 * float64(s.Count) / float64(cs.Predicts)
+}
+
+// ScorelineByCount implements sort.Interface for []Scoreline based on the Count field, most predicted first.
+type ScorelineByCount []Scoreline
+
+func (a ScorelineByCount) Len() int      { return len(a) }
+func (a ScorelineByCount) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ScorelineByCount) Less(i, j int) bool {
+	if a[i].Count != a[j].Count {
+		return a[i].Count > a[j].Count
+	}
+	if a[i].Result1 != a[j].Result1 {
+		return a[i].Result1 < a[j].Result1
+	}
+	return a[i].Result2 < a[j].Result2
+}
+
+// Get a Consensus key given an id.
+func ConsensusKeyById(c appengine.Context, id int64) *datastore.Key {
+	return datastore.NewKey(c, "Consensus", "", id, nil)
+}
+
+// Search for the Consensus entity of a match given a scope and a scope id.
+func FindConsensus(c appengine.Context, matchId int64, scope string, scopeId int64) *Consensus {
+	q := datastore.NewQuery("Consensus").
+		Filter("MatchId"+" =", matchId).
+		Filter("Scope"+" =", scope).
+		Filter("ScopeId"+" =", scopeId)
+
+	var consensuses []*Consensus
+	if _, err := q.GetAll(c, &consensuses); err != nil {
+		log.Errorf(c, "Consensus.Find, error occurred during GetAll: %v", err)
+		return nil
+	}
+	if len(consensuses) == 0 {
+		return nil
+	}
+	return consensuses[0]
+}
+
+// Save an array of Consensus entities, existing entities for the same match and scope are overridden.
+func SaveConsensuses(c appengine.Context, consensuses []*Consensus) error {
+	keys := make([]*datastore.Key, len(consensuses))
+	for i, cs := range consensuses {
+		if old := FindConsensus(c, cs.MatchId, cs.Scope, cs.ScopeId); old != nil {
+			cs.Id = old.Id
+		} else {
+			id, _, err := datastore.AllocateIDs(c, "Consensus", nil, 1)
+			if err != nil {
+				return err
+			}
+			cs.Id = id
+		}
+		keys[i] = ConsensusKeyById(c, cs.Id)
+	}
+	if _, err := datastore.PutMulti(c, keys, consensuses); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Build the consensus of a match for a given scope from the predicts stored in the datastore.
+func (t *Tournament) BuildConsensus(c appengine.Context, m *Tmatch, scope string, scopeId int64) *Consensus {
+	predicts := FindPredicts(c, "MatchId", m.Id)
+
+	switch scope {
+	case ConsensusScopeTournament:
+		predicts = predictsOfUsers(predicts, t.UserIds)
+	case ConsensusScopeTeam:
+		team, err := TeamById(c, scopeId)
+		if err != nil {
+			log.Errorf(c, "Consensus.Build, team not found: %v", err)
+			return nil
+		}
+		predicts = predictsOfUsers(predicts, team.UserIds)
+	default:
+		scopeId = 0
+	}
+	return NewConsensus(m.Id, scope, scopeId, predicts)
+}
+
+// Build the consensus of a match for all scopes: global, tournament and each team of the tournament.
+func (t *Tournament) BuildConsensuses(c appengine.Context, m *Tmatch) []*Consensus {
+	predicts := FindPredicts(c, "MatchId", m.Id)
+
+	consensuses := make([]*Consensus, 0)
+	consensuses = append(consensuses, NewConsensus(m.Id, ConsensusScopeGlobal, 0, predicts))
+	consensuses = append(consensuses, NewConsensus(m.Id, ConsensusScopeTournament, t.Id, predictsOfUsers(predicts, t.UserIds)))
+	for _, team := range t.Teams(c) {
+		consensuses = append(consensuses, NewConsensus(m.Id, ConsensusScopeTeam, team.Id, predictsOfUsers(predicts, team.UserIds)))
+	}
+	return consensuses
+}
+
+// Send a task to compute the crowd consensus of a match.
+func (t *Tournament) UpdateConsensus(c appengine.Context, m *Tmatch) error {
+	desc := "Update consensus:"
+	log.Infof(c, "%s Sending to taskqueue: update consensus", desc)
+
+	b1, errm := json.Marshal(t)
+	if errm != nil {
+		log.Errorf(c, "%s Error marshaling", desc, errm)
+	}
+	b2, errm2 := json.Marshal(m)
+	if errm2 != nil {
+		log.Errorf(c, "%s Error marshaling", desc, errm2)
+	}
+
+	task := taskqueue.NewPOSTTask("/a/update/consensus/", url.Values{
+		"tournament": []string{string(b1)},
+		"match":      []string{string(b2)},
+	})
+
+	if _, err := taskqueue.Add(c, task, "gw-queue"); err != nil {
+		log.Errorf(c, "%s unable to add task to taskqueue.", desc)
+		return err
+	}
+	log.Infof(c, "%s add task to taskqueue successfully", desc)
+	return nil
+}
+
+// Keep the predicts made by the given users.
+func predictsOfUsers(predicts []*Predict, userIds []int64) []*Predict {
+	filtered := make([]*Predict, 0)
+	for _, p := range predicts {
+		for _, id := range userIds {
+			if p.UserId == id {
+				filtered = append(filtered, p)
+				break
+			}
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+)
+
+func TestNewConsensus(t *testing.T) {
+	tests := []struct {
+		name       string
+		predicts   []*Predict
+		wins1      int64
+		draws      int64
+		wins2      int64
+		goals1     int64
+		goals2     int64
+		scorelines []Scoreline
+	}{
+		{
+			name:       "No predicts",
+			predicts:   []*Predict{},
+			scorelines: []Scoreline{},
+		},
+		{
+			name: "Mixed predicts",
+			predicts: []*Predict{
+				{Result1: 1, Result2: 0},
+				{Result1: 2, Result2: 2},
+				{Result1: 1, Result2: 0},
+				{Result1: 0, Result2: 3},
+			},
+			wins1:      2,
+			draws:      1,
+			wins2:      1,
+			goals1:     4,
+			goals2:     5,
+			scorelines: []Scoreline{{1, 0, 2}, {0, 3, 1}, {2, 2, 1}},
+		},
+	}
+	for _, test := range tests {
+		got := NewConsensus(int64(1), ConsensusScopeGlobal, int64(0), test.predicts)
+		if got.Predicts != int64(len(test.predicts)) {
+			t.Errorf("NewConsensus(%q): got %v predicts wanted %v", test.name, got.Predicts, len(test.predicts))
+		}
+		if got.Wins1 != test.wins1 || got.Draws != test.draws || got.Wins2 != test.wins2 {
+			t.Errorf("NewConsensus(%q): got %v/%v/%v wanted %v/%v/%v", test.name, got.Wins1, got.Draws, got.Wins2, test.wins1, test.draws, test.wins2)
+		}
+		if got.Goals1 != test.goals1 || got.Goals2 != test.goals2 {
+			t.Errorf("NewConsensus(%q): got goals %v-%v wanted %v-%v", test.name, got.Goals1, got.Goals2, test.goals1, test.goals2)
+		}
+		if len(got.Scorelines) != len(test.scorelines) {
+			t.Errorf("NewConsensus(%q): got scorelines %v wanted %v", test.name, got.Scorelines, test.scorelines)
+			continue
+		}
+		for i := range got.Scorelines {
+			if got.Scorelines[i] != test.scorelines[i] {
+				t.Errorf("NewConsensus(%q): got scorelines %v wanted %v", test.name, got.Scorelines, test.scorelines)
+				break
+			}
+		}
+	}
+}
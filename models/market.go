@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"time"
+
+	"appengine"
+	"appengine/datastore"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// Side markets a tournament admin can switch on, they come in addition to the exact scoreline predict.
+//
+// The pick of a side predict depends on its market:
+//	overunder:	1 if total goals are over the goals line of the tournament, 0 if under.
+//	btts:		1 if both teams score, 0 otherwise.
+//	margin:		goal difference of the match, result of first team minus result of second team.
+//	firstscorer:	1 or 2 for the team that scores first, 0 if no goal is scored.
+const (
+	MarketOverUnder      = "overunder"
+	MarketBothTeamsScore = "btts"
+	MarketMargin         = "margin"
+	MarketFirstScorer    = "firstscorer"
+)
+
+// Default goals line of the over/under market.
+const defaultGoalsLine = 2.5
+
+// Points earned by a correct pick in each market.
+var marketPoints = map[string]int64{
+	MarketOverUnder:      1,
+	MarketBothTeamsScore: 1,
+	MarketMargin:         2,
+	MarketFirstScorer:    1,
+}
+
+// A SidePredict entity is the pick of a user in a side market of a match.
+type SidePredict struct {
+	Id      int64
+	UserId  int64
+	MatchId int64
+	Market  string
+	Pick    int64
+	Created time.Time
+	Updated time.Time
+}
+
+// Check if a market is a valid side market.
+func IsMarketValid(market string) bool {
+	_, ok := marketPoints[market]
+	return ok
+}
+
+// Points earned by a correct pick in a market.
+func MarketPoints(market string) int64 {
+	return marketPoints[market]
+}
+
+// Check if a pick is valid for a market.
+func IsPickValid(market string, pick int64) bool {
+	switch market {
+	case MarketOverUnder, MarketBothTeamsScore:
+		return pick == 0 || pick == 1
+	case MarketFirstScorer:
+		return pick >= 0 && pick <= 2
+	case MarketMargin:
+		return true
+	}
+	return false
+}
+
+// Check if a market is switched on in the tournament.
+func (t *Tournament) IsMarketEnabled(market string) bool {
+	for _, m := range t.Markets {
+		if m == market {
+			return true
+		}
+	}
+	return false
+}
+
+// Goals line of the over/under market of the tournament.
+func (t *Tournament) OverUnderLine() float64 {
+	if t.GoalsLine <= 0 {
+		return defaultGoalsLine
+	}
+	return t.GoalsLine
+}
+
+// Create a SidePredict entity given a user id, a match id, a market and a pick.
+func CreateSidePredict(c appengine.Context, userId, matchId int64, market string, pick int64) (*SidePredict, error) {
+	sId, _, err := datastore.AllocateIDs(c, "SidePredict", nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	key := datastore.NewKey(c, "SidePredict", "", sId, nil)
+	now := time.Now()
+	s := &SidePredict{sId, userId, matchId, market, pick, now, now}
+	if _, err = datastore.Put(c, key, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get a SidePredict key given an id.
+func SidePredictKeyById(c appengine.Context, id int64) *datastore.Key {
+	return datastore.NewKey(c, "SidePredict", "", id, nil)
+}
+
+// Update a SidePredict entity.
+func (s *SidePredict) Update(c appengine.Context) error {
+	k := SidePredictKeyById(c, s.Id)
+	old := new(SidePredict)
+	if err := datastore.Get(c, k, old); err == nil {
+		if _, err = datastore.Put(c, k, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search for all SidePredict entities with respect of a filter and a value.
+func FindSidePredicts(c appengine.Context, filter string, value interface{}) []*SidePredict {
+	q := datastore.NewQuery("SidePredict").Filter(filter+" =", value)
+
+	var predicts []*SidePredict
+	if _, err := q.GetAll(c, &predicts); err != nil {
+		log.Errorf(c, "FindSidePredicts: error occurred during GetAll: %v", err)
+		return nil
+	}
+	return predicts
+}
+
+// Get the SidePredict entities of a user for a match.
+func SidePredictsByUserMatch(c appengine.Context, userId, matchId int64) []*SidePredict {
+	q := datastore.NewQuery("SidePredict").
+		Filter("UserId"+" =", userId).
+		Filter("MatchId"+" =", matchId)
+
+	var predicts []*SidePredict
+	if _, err := q.GetAll(c, &predicts); err != nil {
+		log.Errorf(c, "SidePredictsByUserMatch: error occurred during GetAll: %v", err)
+		return nil
+	}
+	return predicts
+}
+
+// Group side predicts by user id.
+func SidePredictsByUser(predicts []*SidePredict) map[int64][]*SidePredict {
+	byUser := make(map[int64][]*SidePredict)
+	for _, s := range predicts {
+		byUser[s.UserId] = append(byUser[s.UserId], s)
+	}
+	return byUser
+}
+
+// Check if the pick of a side predict is correct given a finished match and a goals line.
+// The first scorer market cannot be settled when the first scorer of a match with goals is unknown.
+func (s *SidePredict) IsCorrect(m *Tmatch, line float64) bool {
+	goals := m.Result1 + m.Result2
+	switch s.Market {
+	case MarketOverUnder:
+		over := float64(goals) > line
+		return over == (s.Pick == 1)
+	case MarketBothTeamsScore:
+		both := m.Result1 > 0 && m.Result2 > 0
+		return both == (s.Pick == 1)
+	case MarketMargin:
+		return m.Result1-m.Result2 == s.Pick
+	case MarketFirstScorer:
+		if goals > 0 && m.FirstScorer == 0 {
+			return false
+		}
+		return m.FirstScorer == s.Pick
+	}
+	return false
+}
+
+// Compute the points earned by the side predicts of a user in a finished match.
+// Only markets switched on in the tournament are taken into account.
+func (t *Tournament) SideScore(m *Tmatch, predicts []*SidePredict) int64 {
+	score := int64(0)
+	for _, s := range predicts {
+		if t.IsMarketEnabled(s.Market) && s.IsCorrect(m, t.OverUnderLine()) {
+			score += MarketPoints(s.Market)
+		}
+	}
+	return score
+}
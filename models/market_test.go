@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+)
+
+func TestSideScore(t *testing.T) {
+	tournament := &Tournament{Markets: []string{MarketOverUnder, MarketBothTeamsScore, MarketMargin, MarketFirstScorer}}
+
+	tests := []struct {
+		name     string
+		match    Tmatch
+		predicts []*SidePredict
+		want     int64
+	}{
+		{
+			name:  "All correct",
+			match: Tmatch{Result1: 2, Result2: 1, FirstScorer: 2},
+			predicts: []*SidePredict{
+				{Market: MarketOverUnder, Pick: 1},
+				{Market: MarketBothTeamsScore, Pick: 1},
+				{Market: MarketMargin, Pick: 1},
+				{Market: MarketFirstScorer, Pick: 2},
+			},
+			want: 5,
+		},
+		{
+			name:  "All wrong",
+			match: Tmatch{Result1: 0, Result2: 2, FirstScorer: 2},
+			predicts: []*SidePredict{
+				{Market: MarketOverUnder, Pick: 1},
+				{Market: MarketBothTeamsScore, Pick: 1},
+				{Market: MarketMargin, Pick: 2},
+				{Market: MarketFirstScorer, Pick: 1},
+			},
+			want: 0,
+		},
+		{
+			name:  "No goal",
+			match: Tmatch{Result1: 0, Result2: 0},
+			predicts: []*SidePredict{
+				{Market: MarketOverUnder, Pick: 0},
+				{Market: MarketMargin, Pick: 0},
+				{Market: MarketFirstScorer, Pick: 0},
+			},
+			want: 4,
+		},
+		{
+			name:  "Unknown first scorer",
+			match: Tmatch{Result1: 1, Result2: 0},
+			predicts: []*SidePredict{
+				{Market: MarketFirstScorer, Pick: 0},
+			},
+			want: 0,
+		},
+	}
+	for _, test := range tests {
+		if got := tournament.SideScore(&test.match, test.predicts); got != test.want {
+			t.Errorf("SideScore(%q): got %d wanted %d", test.name, got, test.want)
+		}
+	}
+
+	disabled := &Tournament{Markets: []string{MarketMargin}}
+	predicts := []*SidePredict{{Market: MarketOverUnder, Pick: 0}, {Market: MarketMargin, Pick: 0}}
+	if got := disabled.SideScore(&Tmatch{Result1: 1, Result2: 1}, predicts); got != 2 {
+		t.Errorf("SideScore(disabled market): got %d wanted %d", got, 2)
+	}
+}
@@ -29,29 +29,68 @@ import (
 
 // A Predict entity is defined by the result of a Match: Result1 and Result2 a match id and a user id.
 type Predict struct {
-	Id      int64     // predict id
-	UserId  int64     // user id, a prediction is binded to a single user.
-	Result1 int64     // result of first team
-	Result2 int64     // result of second team
-	MatchId int64     // match id in tournament
-	Created time.Time // date of creation
+	Id        int64     // predict id
+	UserId    int64     // user id, a prediction is binded to a single user.
+	Result1   int64     // result of first team
+	Result2   int64     // result of second team
+	MatchId   int64     // match id in tournament
+	Created   time.Time // date of creation
+	Updated   time.Time // date of last change of the results
+	Automatic bool      // the predict was created by the auto predict setting of the user.
 }
 
 // Create a Predict entity given a name, a user id, a result and a match id admin id and a private mode.
 func CreatePredict(c appengine.Context, userId, result1, result2, matchId int64) (*Predict, error) {
+	return createPredict(c, userId, result1, result2, matchId, false)
+}
+
+// Create a Predict entity flagged as automatic given a user id, a result and a match id.
+func CreateAutomaticPredict(c appengine.Context, userId, result1, result2, matchId int64) (*Predict, error) {
+	return createPredict(c, userId, result1, result2, matchId, true)
+}
+
+func createPredict(c appengine.Context, userId, result1, result2, matchId int64, automatic bool) (*Predict, error) {
 
 	pId, _, err := datastore.AllocateIDs(c, "Predict", nil, 1)
 	if err != nil {
 		return nil, err
 	}
 	key := datastore.NewKey(c, "Predict", "", pId, nil)
-	p := &Predict{pId, userId, result1, result2, matchId, time.Now()}
+	now := time.Now()
+	p := &Predict{pId, userId, result1, result2, matchId, now, now, automatic}
 	if _, err = datastore.Put(c, key, p); err != nil {
 		return nil, err
 	}
+	if _, err = CreatePredictRevision(c, p); err != nil {
+		log.Errorf(c, "Predict.Create: unable to create revision of predict %v: %v", p.Id, err)
+	}
 	return p, nil
 }
 
+// Change the results of a predict, the change is kept in the revision log of the predict.
+func (p *Predict) Change(c appengine.Context, result1, result2 int64) error {
+	p.Result1 = result1
+	p.Result2 = result2
+	p.Updated = time.Now()
+	p.Automatic = false
+	if err := p.Update(c); err != nil {
+		return err
+	}
+	if _, err := CreatePredictRevision(c, p); err != nil {
+		log.Errorf(c, "Predict.Change: unable to create revision of predict %v: %v", p.Id, err)
+	}
+	return nil
+}
+
+// Date the results of the predict were last submitted.
+func (p *Predict) SubmittedAt() time.Time {
+	if p.Updated.IsZero() {
+		// predicts created before updates were tracked.
+		return p.Created
+	}
+	return p.Updated
+}
+
 // Destroy a Predict entity.
 func (p *Predict) Destroy(c appengine.Context) error {
 
@@ -171,22 +210,124 @@ func PredictsByIds2(c appengine.Context, ids []int64) ([]*Predict, error) {
 	for i, _ := range keys {
 		keys[i] = PredictKeyById(c, ids[i])
 	}
-	var predicts []*Predict
-	if err := datastore.GetMulti(c, keys, predicts); err != nil {
+	found := make([]Predict, len(ids))
+	missing := make(map[int]bool)
+	if err := datastore.GetMulti(c, keys, found); err != nil {
 		if me, ok := err.(appengine.MultiError); ok {
 			for i, merr := range me {
 				if merr == datastore.ErrNoSuchEntity {
-					log.Errorf(c, "Predict.ByIds2, key[%v] is missing: %v", i)
-
+					log.Errorf(c, "Predict.ByIds2, key[%v] is missing: %v", i, merr)
+					missing[i] = true
+				} else if merr != nil {
+					return nil, merr
 				}
 			}
 		} else {
 			return nil, err
 		}
 	}
+	predicts := make([]*Predict, 0)
+	for i := range found {
+		if !missing[i] {
+			predicts = append(predicts, &found[i])
+		}
+	}
+	return predicts, nil
+}
+
+// Create or update the predicts of a user for an array of matches with batched datastore writes.
+// A revision is created for each predict and the user entity is updated once with the new predict ids.
+func (u *User) SetPredicts(c appengine.Context, matchIds []int64, results1 []int64, results2 []int64) ([]*Predict, error) {
+	desc := "Set Predicts:"
+	if len(matchIds) != len(results1) || len(matchIds) != len(results2) {
+		log.Errorf(c, "%s unable to set predicts, arrays do not have the same length", desc)
+		return nil, errors.New("model/predict: unable to set predicts")
+	}
+	if len(matchIds) == 0 {
+		return []*Predict{}, nil
+	}
+
+	userPredicts, err := u.predictsByMatches(c, matchIds)
+	if err != nil {
+		log.Errorf(c, "%s unable to get predicts of user %v: %v", desc, u.Id, err)
+		return nil, err
+	}
+
+	// count new predicts to allocate their ids in a single call.
+	newCount := 0
+	for _, id := range matchIds {
+		if ok, _ := userPredicts.ContainsMatchId(id); !ok {
+			newCount++
+		}
+	}
+	var nextId int64
+	if newCount > 0 {
+		if nextId, _, err = datastore.AllocateIDs(c, "Predict", nil, newCount); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	predicts := make([]*Predict, len(matchIds))
+	keys := make([]*datastore.Key, len(matchIds))
+	newIds := make([]int64, 0)
+	for i, id := range matchIds {
+		if ok, j := userPredicts.ContainsMatchId(id); ok {
+			predicts[i] = userPredicts[j]
+			predicts[i].Result1 = results1[i]
+			predicts[i].Result2 = results2[i]
+			predicts[i].Updated = now
+			predicts[i].Automatic = false
+		} else {
+			predicts[i] = &Predict{nextId, u.Id, results1[i], results2[i], id, now, now, false}
+			newIds = append(newIds, nextId)
+			nextId++
+		}
+		keys[i] = PredictKeyById(c, predicts[i].Id)
+	}
+
+	if _, err := datastore.PutMulti(c, keys, predicts); err != nil {
+		log.Errorf(c, "%s unable to put predicts: %v", desc, err)
+		return nil, err
+	}
+	if err := CreatePredictRevisions(c, predicts); err != nil {
+		log.Errorf(c, "%s unable to create revisions of predicts: %v", desc, err)
+	}
+	if len(newIds) > 0 {
+		u.PredictIds = append(u.PredictIds, newIds...)
+		if err := u.Update(c); err != nil {
+			return nil, err
+		}
+	}
 	return predicts, nil
 }
 
+// Get the predicts of a user for an array of matches, only the predicts of these matches are loaded.
+func (u *User) predictsByMatches(c appengine.Context, matchIds []int64) (Predicts, error) {
+	predicts := make(Predicts, 0)
+	for _, id := range matchIds {
+		q := datastore.NewQuery("Predict").
+			Filter("UserId"+" =", u.Id).
+			Filter("MatchId"+" =", id)
+		var found []*Predict
+		if _, err := q.GetAll(c, &found); err != nil {
+			return nil, err
+		}
+		predicts = append(predicts, found...)
+	}
+	return predicts, nil
+}
+
+// Tells if the predict of a match can be seen by a viewer.
+// A user always sees his own predicts. Predicts of other users are hidden until the match is locked,
+// unless the team through which they are seen reveals its members predicts earlier.
+func (p *Predict) IsVisibleBy(viewerId int64, m *Tmatch, team *Team) bool {
+	if p.UserId == viewerId || m.IsLocked() {
+		return true
+	}
+	return team != nil && team.RevealPredicts
+}
+
 type Predicts []*Predict
 
 func (a Predicts) ContainsMatchId(id int64) (bool, int) {
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"sort"
+	"time"
+
+	"appengine"
+	"appengine/datastore"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// A PredictRevision entity keeps a version of the results of a predict.
+//
+// Revisions are never updated nor deleted, a new revision is created each time a predict is set.
+type PredictRevision struct {
+	Id        int64     // revision id
+	PredictId int64     // predict id
+	UserId    int64     // user id
+	MatchId   int64     // match id in tournament
+	Result1   int64     // result of first team
+	Result2   int64     // result of second team
+	Created   time.Time // date the results were submitted
+}
+
+// Create a PredictRevision entity from the current results of a predict.
+func CreatePredictRevision(c appengine.Context, p *Predict) (*PredictRevision, error) {
+	rId, _, err := datastore.AllocateIDs(c, "PredictRevision", nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	key := datastore.NewKey(c, "PredictRevision", "", rId, nil)
+	r := &PredictRevision{rId, p.Id, p.UserId, p.MatchId, p.Result1, p.Result2, p.SubmittedAt()}
+	if _, err = datastore.Put(c, key, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Create a PredictRevision entity for each predict of an array, with batched datastore writes.
+func CreatePredictRevisions(c appengine.Context, predicts []*Predict) error {
+	if len(predicts) == 0 {
+		return nil
+	}
+	low, _, err := datastore.AllocateIDs(c, "PredictRevision", nil, len(predicts))
+	if err != nil {
+		return err
+	}
+	keys := make([]*datastore.Key, len(predicts))
+	revisions := make([]*PredictRevision, len(predicts))
+	for i, p := range predicts {
+		id := low + int64(i)
+		keys[i] = datastore.NewKey(c, "PredictRevision", "", id, nil)
+		revisions[i] = &PredictRevision{id, p.Id, p.UserId, p.MatchId, p.Result1, p.Result2, p.SubmittedAt()}
+	}
+	if _, err := datastore.PutMulti(c, keys, revisions); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Get the revisions of a predict, oldest first.
+func PredictRevisions(c appengine.Context, predictId int64) []*PredictRevision {
+	q := datastore.NewQuery("PredictRevision").Filter("PredictId"+" =", predictId)
+
+	var revisions []*PredictRevision
+	if _, err := q.GetAll(c, &revisions); err != nil {
+		log.Errorf(c, "PredictRevisions: error occurred during GetAll: %v", err)
+		return nil
+	}
+	sort.Sort(PredictRevisionByDate(revisions))
+	return revisions
+}
+
+// Sort predict revisions by date of submission.
+type PredictRevisionByDate []*PredictRevision
+
+func (a PredictRevisionByDate) Len() int           { return len(a) }
+func (a PredictRevisionByDate) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a PredictRevisionByDate) Less(i, j int) bool { return a[i].Created.Before(a[j].Created) }
@@ -0,0 +1,314 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	"appengine"
+	"appengine/datastore"
+	"appengine/taskqueue"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// Kinds of question.
+const (
+	QuestionChoice  = "choice"  // the answer is the index of one of the choices.
+	QuestionNumeric = "numeric" // the answer is a number.
+)
+
+// Maximum points of a question, it keeps a question below the points of a few exact predicts.
+const QuestionMaxPoints = 10
+
+// A Question entity is a free-form question defined by an admin on a match or a tournament.
+//
+// A question defined by a team admin can only be answered by the members of the team.
+// Once a question is resolved, the users who gave the right answer earn its points in their tournament score.
+type Question struct {
+	Id           int64
+	TournamentId int64
+	MatchId      int64 // id of the match the question is about, 0 for a tournament question.
+	TeamId       int64 // id of the team the question is restricted to, 0 for all participants.
+	AuthorId     int64
+	Text         string
+	Kind         string
+	Choices      []string
+	Points       int64
+	Deadline     time.Time // answers are accepted until the deadline.
+	Resolved     bool
+	Answer       int64 // right answer, set when the question is resolved.
+	Created      time.Time
+}
+
+// An Answer entity is the answer of a user to a question.
+type Answer struct {
+	Id         int64
+	QuestionId int64
+	UserId     int64
+	Value      int64
+	Created    time.Time
+	Updated    time.Time
+}
+
+// Check if a kind is a valid question kind.
+func IsQuestionKindValid(kind string) bool {
+	return kind == QuestionChoice || kind == QuestionNumeric
+}
+
+// Create a Question entity.
+func CreateQuestion(c appengine.Context, q *Question) (*Question, error) {
+	qId, _, err := datastore.AllocateIDs(c, "Question", nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	key := datastore.NewKey(c, "Question", "", qId, nil)
+	q.Id = qId
+	q.Created = time.Now()
+	if _, err = datastore.Put(c, key, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Get a Question key given an id.
+func QuestionKeyById(c appengine.Context, id int64) *datastore.Key {
+	return datastore.NewKey(c, "Question", "", id, nil)
+}
+
+// Get a Question entity given an id.
+func QuestionById(c appengine.Context, id int64) (*Question, error) {
+	var q Question
+	if err := datastore.Get(c, QuestionKeyById(c, id), &q); err != nil {
+		log.Errorf(c, "question not found : %v", err)
+		return nil, err
+	}
+	return &q, nil
+}
+
+// Update a Question entity.
+func (q *Question) Update(c appengine.Context) error {
+	k := QuestionKeyById(c, q.Id)
+	old := new(Question)
+	if err := datastore.Get(c, k, old); err == nil {
+		if _, err = datastore.Put(c, k, q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Destroy a Question entity and its answers.
+func (q *Question) Destroy(c appengine.Context) error {
+	answers := AnswersByQuestion(c, q.Id)
+	keys := make([]*datastore.Key, len(answers))
+	for i, a := range answers {
+		keys[i] = AnswerKeyById(c, a.Id)
+	}
+	if err := datastore.DeleteMulti(c, keys); err != nil {
+		return err
+	}
+	return datastore.Delete(c, QuestionKeyById(c, q.Id))
+}
+
+// Get the questions of a tournament.
+func QuestionsByTournament(c appengine.Context, tournamentId int64) []*Question {
+	q := datastore.NewQuery("Question").Filter("TournamentId"+" =", tournamentId)
+
+	var questions []*Question
+	if _, err := q.GetAll(c, &questions); err != nil {
+		log.Errorf(c, "QuestionsByTournament: error occurred during GetAll: %v", err)
+		return nil
+	}
+	return questions
+}
+
+// Check if the question still accepts answers.
+func (q *Question) IsOpen() bool {
+	return !q.Resolved && time.Now().Before(q.Deadline)
+}
+
+// Check if a value is a valid answer to the question.
+func (q *Question) IsValidAnswer(value int64) bool {
+	if q.Kind == QuestionChoice {
+		return value >= 0 && value < int64(len(q.Choices))
+	}
+	return true
+}
+
+// Check if a user can see and answer the question, team questions are restricted to the members of the team.
+func (q *Question) IsVisibleBy(u *User) bool {
+	if q.TeamId == 0 {
+		return true
+	}
+	ok, _ := u.ContainsTeamId(q.TeamId)
+	return ok
+}
+
+// Get the ids of the users who gave the right answer to a resolved question.
+func (q *Question) Winners(answers []*Answer) []int64 {
+	winners := make([]int64, 0)
+	if !q.Resolved {
+		return winners
+	}
+	for _, a := range answers {
+		if a.QuestionId == q.Id && a.Value == q.Answer {
+			winners = append(winners, a.UserId)
+		}
+	}
+	return winners
+}
+
+// Credit the points of the question to a user and to his tournament score entity.
+// The question is recorded in the score entity so its points are credited only once.
+// Returns true if the points were credited.
+func (q *Question) Credit(c appengine.Context, userId, scoreId int64) (bool, error) {
+	credited := false
+	err := datastore.RunInTransaction(c, func(c appengine.Context) error {
+		credited = false
+		var s Score
+		if err := datastore.Get(c, ScoreKeyById(c, scoreId), &s); err != nil {
+			return err
+		}
+		if s.HasQuestion(q.Id) {
+			return nil
+		}
+		var u User
+		if err := datastore.Get(c, UserKeyById(c, userId), &u); err != nil {
+			return err
+		}
+		s.QuestionIds = append(s.QuestionIds, q.Id)
+		s.QuestionPoints = append(s.QuestionPoints, q.Points)
+		u.Score += q.Points
+		if _, err := datastore.Put(c, ScoreKeyById(c, scoreId), &s); err != nil {
+			return err
+		}
+		if _, err := datastore.Put(c, UserKeyById(c, userId), &u); err != nil {
+			return err
+		}
+		credited = true
+		return nil
+	}, &datastore.TransactionOptions{XG: true})
+	return credited, err
+}
+
+// Resolve the question with its right answer and send a task to give the points to the winners.
+func (q *Question) Resolve(c appengine.Context, answer int64) error {
+	if q.Resolved {
+		return errors.New("model/question: question already resolved")
+	}
+	q.Resolved = true
+	q.Answer = answer
+	if err := q.Update(c); err != nil {
+		return err
+	}
+
+	desc := "Resolve question:"
+	log.Infof(c, "%s Sending to taskqueue: update questions scores", desc)
+
+	b, errm := json.Marshal(q)
+	if errm != nil {
+		log.Errorf(c, "%s Error marshaling", desc, errm)
+	}
+
+	task := taskqueue.NewPOSTTask("/a/update/questions/scores/", url.Values{
+		"question": []string{string(b)},
+	})
+
+	if _, err := taskqueue.Add(c, task, "gw-queue"); err != nil {
+		log.Errorf(c, "%s unable to add task to taskqueue.", desc)
+		return err
+	}
+	log.Infof(c, "%s add task to taskqueue successfully", desc)
+	return nil
+}
+
+// Create an Answer entity given a question, a user and a value.
+func CreateAnswer(c appengine.Context, questionId, userId, value int64) (*Answer, error) {
+	aId, _, err := datastore.AllocateIDs(c, "Answer", nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	key := datastore.NewKey(c, "Answer", "", aId, nil)
+	now := time.Now()
+	a := &Answer{aId, questionId, userId, value, now, now}
+	if _, err = datastore.Put(c, key, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Get an Answer key given an id.
+func AnswerKeyById(c appengine.Context, id int64) *datastore.Key {
+	return datastore.NewKey(c, "Answer", "", id, nil)
+}
+
+// Update an Answer entity.
+func (a *Answer) Update(c appengine.Context) error {
+	k := AnswerKeyById(c, a.Id)
+	old := new(Answer)
+	if err := datastore.Get(c, k, old); err == nil {
+		if _, err = datastore.Put(c, k, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the answers to a question.
+func AnswersByQuestion(c appengine.Context, questionId int64) []*Answer {
+	q := datastore.NewQuery("Answer").Filter("QuestionId"+" =", questionId)
+
+	var answers []*Answer
+	if _, err := q.GetAll(c, &answers); err != nil {
+		log.Errorf(c, "AnswersByQuestion: error occurred during GetAll: %v", err)
+		return nil
+	}
+	return answers
+}
+
+// Get the answers of a user.
+func AnswersByUser(c appengine.Context, userId int64) []*Answer {
+	q := datastore.NewQuery("Answer").Filter("UserId"+" =", userId)
+
+	var answers []*Answer
+	if _, err := q.GetAll(c, &answers); err != nil {
+		log.Errorf(c, "AnswersByUser: error occurred during GetAll: %v", err)
+		return nil
+	}
+	return answers
+}
+
+// Get the answer of a user to a question.
+func AnswerByUserQuestion(c appengine.Context, userId, questionId int64) *Answer {
+	q := datastore.NewQuery("Answer").
+		Filter("UserId"+" =", userId).
+		Filter("QuestionId"+" =", questionId)
+
+	var answers []*Answer
+	if _, err := q.GetAll(c, &answers); err != nil {
+		log.Errorf(c, "AnswerByUserQuestion: error occurred during GetAll: %v", err)
+		return nil
+	}
+	if len(answers) == 0 {
+		return nil
+	}
+	return answers[0]
+}
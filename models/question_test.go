@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+)
+
+func TestQuestionWinners(t *testing.T) {
+	answers := []*Answer{
+		{QuestionId: 1, UserId: 1, Value: 2},
+		{QuestionId: 1, UserId: 2, Value: 0},
+		{QuestionId: 1, UserId: 3, Value: 2},
+		{QuestionId: 2, UserId: 4, Value: 2},
+	}
+
+	q := &Question{Id: 1, Kind: QuestionChoice, Choices: []string{"Yes", "No", "Maybe"}}
+	if got := q.Winners(answers); len(got) != 0 {
+		t.Errorf("Winners(unresolved): got %v wanted no winner", got)
+	}
+
+	q.Resolved = true
+	q.Answer = 2
+	got := q.Winners(answers)
+	want := []int64{1, 3}
+	if len(got) != len(want) {
+		t.Fatalf("Winners: got %v wanted %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("Winners: got %v wanted %v", got, want)
+		}
+	}
+
+	if q.IsValidAnswer(3) || q.IsValidAnswer(-1) || !q.IsValidAnswer(0) {
+		t.Errorf("IsValidAnswer: wrong validation of choices %v", q.Choices)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"math"
+
+	"appengine"
+)
+
+// Compute the rarity bonus of the participants of the tournament for a finished match.
+// Returns a map of bonuses by user id, users without bonus are not in the map.
+func (t *Tournament) RarityBonuses(c appengine.Context, m *Tmatch) map[int64]int64 {
+	predicts := predictsOfUsers(FindPredicts(c, "MatchId", m.Id), t.UserIds)
+	return rarityBonuses(m, predicts)
+}
+
+// Compute the rarity bonus of each predict of a finished match.
+//
+// The bonus of a correct predict scales with how rare it was among the predicts of the match:
+// it is the score of the predict times the share of predicts that did not earn the same score.
+// A perfect result predicted by 2% of the participants is worth almost twice its score,
+// the same result predicted by 80% of the participants only gets a fifth of its score as bonus.
+func rarityBonuses(m *Tmatch, predicts []*Predict) map[int64]int64 {
+	bonuses := make(map[int64]int64)
+	if len(predicts) == 0 {
+		return bonuses
+	}
+
+	scores := make(map[int64]int64)
+	counts := make(map[int64]int64)
+	for _, p := range predicts {
+		scores[p.UserId] = computeScore(nil, m, p)
+		counts[scores[p.UserId]]++
+	}
+
+	for userId, score := range scores {
+		if score == 0 {
+			continue
+		}
+		share := float64(counts[score]) / float64(len(predicts))
+		if bonus := int64(math.Floor(float64(score)*(1-share) + 0.5)); bonus > 0 {
+			bonuses[userId] = bonus
+		}
+	}
+	return bonuses
+}
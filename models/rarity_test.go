@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+)
+
+func TestRarityBonuses(t *testing.T) {
+	m := &Tmatch{Result1: 1, Result2: 0}
+
+	tests := []struct {
+		name     string
+		predicts []*Predict
+		want     map[int64]int64
+	}{
+		{
+			name: "Rare exact result",
+			predicts: []*Predict{
+				{UserId: 1, Result1: 2}, {UserId: 2, Result1: 2}, {UserId: 3, Result1: 2}, {UserId: 4, Result1: 2},
+				{UserId: 5, Result1: 2}, {UserId: 6, Result1: 2}, {UserId: 7, Result1: 2}, {UserId: 8, Result1: 2},
+				{UserId: 9, Result1: 1}, {UserId: 10, Result1: 0},
+			},
+			want: map[int64]int64{9: 3},
+		},
+		{
+			name: "Shared results",
+			predicts: []*Predict{
+				{UserId: 1, Result1: 1}, {UserId: 2, Result1: 1}, {UserId: 3, Result1: 3, Result2: 1}, {UserId: 4, Result2: 1},
+			},
+			want: map[int64]int64{1: 2, 2: 2, 3: 1},
+		},
+		{
+			name:     "No predicts",
+			predicts: []*Predict{},
+			want:     map[int64]int64{},
+		},
+	}
+	for _, test := range tests {
+		got := rarityBonuses(m, test.predicts)
+		if len(got) != len(test.want) {
+			t.Errorf("rarityBonuses(%q): got %v wanted %v", test.name, got, test.want)
+			continue
+		}
+		for id, want := range test.want {
+			if got[id] != want {
+				t.Errorf("rarityBonuses(%q): got %d for user %d wanted %d", test.name, got[id], id, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"sort"
+
+	"appengine"
+	"appengine/datastore"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// Competitions a tournament can belong to.
+const (
+	CompetitionWorldCup        = "worldcup"
+	CompetitionChampionsLeague = "championsleague"
+)
+
+// A Rating entity holds the predictions record of a user in a tournament.
+//
+// The predictor rating of a user is the number of points he earned per predicted match.
+// Unlike the score of a user, it does not grow with the number of tournaments he joins.
+type Rating struct {
+	Id           int64
+	UserId       int64
+	TournamentId int64
+	Season       int64   // year the tournament starts.
+	Competition  string  // competition of the tournament.
+	Matches      int64   // number of finished matches predicted by the user.
+	Points       int64   // points earned in these matches.
+	MatchIds     []int64 // ids of the matches already taken into account.
+}
+
+// UserRating holds the predictor rating of a user over a set of tournaments.
+type UserRating struct {
+	UserId  int64
+	Matches int64
+	Points  int64
+	Rating  float64 // points per predicted match, between 0 and 3.
+}
+
+// Create a Rating entity given a user and a tournament.
+func CreateRating(c appengine.Context, userId int64, t *Tournament) (*Rating, error) {
+	rId, _, err := datastore.AllocateIDs(c, "Rating", nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	key := datastore.NewKey(c, "Rating", "", rId, nil)
+	r := &Rating{rId, userId, t.Id, int64(t.Start.Year()), t.Competition(), 0, 0, make([]int64, 0)}
+	if _, err = datastore.Put(c, key, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Get a Rating key given an id.
+func RatingKeyById(c appengine.Context, id int64) *datastore.Key {
+	return datastore.NewKey(c, "Rating", "", id, nil)
+}
+
+// Update a Rating entity.
+func (r *Rating) Update(c appengine.Context) error {
+	k := RatingKeyById(c, r.Id)
+	old := new(Rating)
+	if err := datastore.Get(c, k, old); err == nil {
+		if _, err = datastore.Put(c, k, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search for the Rating entity of a user in a tournament.
+func RatingByUserTournament(c appengine.Context, userId, tournamentId int64) *Rating {
+	q := datastore.NewQuery("Rating").
+		Filter("UserId"+" =", userId).
+		Filter("TournamentId"+" =", tournamentId)
+
+	var ratings []*Rating
+	if _, err := q.GetAll(c, &ratings); err != nil {
+		log.Errorf(c, "RatingByUserTournament: error occurred during GetAll: %v", err)
+		return nil
+	}
+	if len(ratings) == 0 {
+		return nil
+	}
+	return ratings[0]
+}
+
+// Get the Rating entities of a user, one for each tournament he played.
+func RatingsByUser(c appengine.Context, userId int64) []*Rating {
+	q := datastore.NewQuery("Rating").Filter("UserId"+" =", userId)
+
+	var ratings []*Rating
+	if _, err := q.GetAll(c, &ratings); err != nil {
+		log.Errorf(c, "RatingsByUser: error occurred during GetAll: %v", err)
+		return nil
+	}
+	return ratings
+}
+
+// Points per predicted match in the tournament of the rating.
+func (r *Rating) Value() float64 {
+	if r.Matches == 0 {
+		return 0
+	}
+	return float64(r.Points) / float64(r.Matches)
+}
+
+// Search for Rating entities with respect to a season and a competition.
+// A season equal to 0 or an empty competition means no filter.
+func FindRatings(c appengine.Context, season int64, competition string) []*Rating {
+	q := datastore.NewQuery("Rating")
+	if season > 0 {
+		q = q.Filter("Season"+" =", season)
+	}
+	if len(competition) > 0 {
+		q = q.Filter("Competition"+" =", competition)
+	}
+
+	var ratings []*Rating
+	if _, err := q.GetAll(c, &ratings); err != nil {
+		log.Errorf(c, "FindRatings: error occurred during GetAll: %v", err)
+		return nil
+	}
+	return ratings
+}
+
+// Add the score of a finished match to the rating.
+// Returns false if the match was already taken into account.
+func (r *Rating) Add(matchId int64, score int64) bool {
+	for _, id := range r.MatchIds {
+		if id == matchId {
+			return false
+		}
+	}
+	r.MatchIds = append(r.MatchIds, matchId)
+	r.Matches++
+	r.Points += score
+	return true
+}
+
+// Update the rating of a user in a tournament with respect to a finished match.
+// When the rating does not exist yet, all finished matches of the tournament are taken into account.
+func (u *User) UpdateRating(c appengine.Context, t *Tournament, m *Tmatch) error {
+	predicts := Predicts(PredictsByIds(c, u.PredictIds))
+
+	r := RatingByUserTournament(c, u.Id, t.Id)
+	if r == nil {
+		var err error
+		if r, err = CreateRating(c, u.Id, t); err != nil {
+			return err
+		}
+		for _, old := range GetAllMatchesFromTournament(c, t) {
+			if !old.Finished || old.Id == m.Id {
+				continue
+			}
+			if ok, i := predicts.ContainsMatchId(old.Id); ok {
+				r.Add(old.Id, computeScore(c, old, predicts[i]))
+			}
+		}
+	}
+
+	if ok, i := predicts.ContainsMatchId(m.Id); ok {
+		r.Add(m.Id, computeScore(c, m, predicts[i]))
+	}
+	return r.Update(c)
+}
+
+// Get the ranking of users by predictor rating with respect to a season and a competition.
+// Users with less than minMatches predicted matches are not ranked.
+func RankingByRating(c appengine.Context, season int64, competition string, minMatches int64, limit int) []*UserRating {
+	ratings := FindRatings(c, season, competition)
+	return rankRatings(ratings, minMatches, limit)
+}
+
+// Aggregate ratings by user and sort them by rating, best first.
+func rankRatings(ratings []*Rating, minMatches int64, limit int) []*UserRating {
+	byUser := make(map[int64]*UserRating)
+	users := make([]*UserRating, 0)
+	for _, r := range ratings {
+		ur, ok := byUser[r.UserId]
+		if !ok {
+			ur = &UserRating{UserId: r.UserId}
+			byUser[r.UserId] = ur
+			users = append(users, ur)
+		}
+		ur.Matches += r.Matches
+		ur.Points += r.Points
+	}
+
+	ranked := make([]*UserRating, 0)
+	for _, ur := range users {
+		if ur.Matches == 0 || ur.Matches < minMatches {
+			continue
+		}
+		ur.Rating = float64(ur.Points) / float64(ur.Matches)
+		ranked = append(ranked, ur)
+	}
+	sort.Sort(UserRatingByRating(ranked))
+	if limit >= 0 && len(ranked) > limit {
+		return ranked[:limit]
+	}
+	return ranked
+}
+
+// Sort user ratings by rating, best first. Ties are broken by the number of predicted matches.
+type UserRatingByRating []*UserRating
+
+func (a UserRatingByRating) Len() int      { return len(a) }
+func (a UserRatingByRating) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a UserRatingByRating) Less(i, j int) bool {
+	if a[i].Rating != a[j].Rating {
+		return a[i].Rating > a[j].Rating
+	}
+	return a[i].Matches > a[j].Matches
+}
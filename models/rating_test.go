@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+)
+
+func TestRankRatings(t *testing.T) {
+	ratings := []*Rating{
+		{UserId: 1, TournamentId: 1, Matches: 10, Points: 10},
+		{UserId: 1, TournamentId: 2, Matches: 10, Points: 20},
+		{UserId: 2, TournamentId: 1, Matches: 4, Points: 12},
+		{UserId: 3, TournamentId: 1, Matches: 10, Points: 20},
+		{UserId: 4, TournamentId: 2, Matches: 0, Points: 0},
+	}
+
+	tests := []struct {
+		name       string
+		minMatches int64
+		limit      int
+		want       []int64
+	}{
+		{name: "All users", minMatches: 0, limit: 10, want: []int64{2, 3, 1}},
+		{name: "Minimum matches", minMatches: 5, limit: 10, want: []int64{3, 1}},
+		{name: "Limit", minMatches: 0, limit: 1, want: []int64{2}},
+	}
+	for _, test := range tests {
+		got := rankRatings(ratings, test.minMatches, test.limit)
+		if len(got) != len(test.want) {
+			t.Errorf("rankRatings(%q): got %d users wanted %d", test.name, len(got), len(test.want))
+			continue
+		}
+		for i := range got {
+			if got[i].UserId != test.want[i] {
+				t.Errorf("rankRatings(%q): got user %v at position %d wanted %v", test.name, got[i].UserId, i, test.want[i])
+			}
+		}
+	}
+}
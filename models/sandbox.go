@@ -0,0 +1,291 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"appengine"
+	"appengine/datastore"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// Maximum number of sandboxes of a tournament for a user, each sandbox copies the whole tournament.
+const maxSandboxes = 3
+
+// A Sandbox is a practice replay of a finished tournament by a user, e.g. the World Cup 2014 between two major tournaments.
+// Its tournament is a copy of the structure of the replayed one whose results are hidden, they are revealed
+// match by match as the user predicts. The score of a sandbox is kept apart from the rankings of the users and teams.
+// It is keyed by the id of its tournament.
+type Sandbox struct {
+	Id           int64     // id of the tournament of the sandbox.
+	TournamentId int64     // id of the replayed tournament.
+	UserId       int64     // id of the user playing the sandbox.
+	MatchIds     []int64   // ids of the revealed matches, in the order they were predicted.
+	Results1     []int64   // predicts of the user for the revealed matches.
+	Results2     []int64   // predicts of the user for the revealed matches.
+	Points       []int64   // points earned on each revealed match.
+	Score        int64     // sum of the points.
+	Created      time.Time // date of creation
+}
+
+// Check if the tournament is a practice sandbox.
+func (t *Tournament) IsSandbox() bool {
+	return t.ReplayOf != 0
+}
+
+// Create a practice sandbox of a finished tournament for a user.
+// The sandbox tournament is private: the user is its only participant, it has no administrators and it is not listed nor searchable.
+// It has its own name, so it is never mistaken for the replayed tournament. A user has at most 3 sandboxes of a tournament.
+func (t *Tournament) CreateSandbox(c appengine.Context, u *User) (*Sandbox, error) {
+	desc := "Tournament.CreateSandbox:"
+	if t.IsSandbox() || !t.IsFinished(c) {
+		return nil, errors.New(fmt.Sprintf("tournament %d cannot be replayed", t.Id))
+	}
+	count := 0
+	for _, s := range SandboxesByUser(c, u.Id) {
+		if s.TournamentId == t.Id {
+			count++
+		}
+	}
+	if count >= maxSandboxes {
+		return nil, errors.New(helpers.ErrorCodeSandboxLimit)
+	}
+
+	st, err := t.Clone(c, t.Name, t.Start, u.Id)
+	if err != nil {
+		return nil, err
+	}
+	st.Name = fmt.Sprintf("%s sandbox %d", t.Name, st.Id)
+	st.ReplayOf = t.Id
+	st.UserIds = []int64{u.Id}
+	st.Wagering = false
+	st.AdminIds = []int64{}
+	if err := st.Update(c); err != nil {
+		return nil, err
+	}
+	if err := UpdateTournamentInvertedIndex(c, st.KeyName, "", st.Id); err != nil {
+		log.Errorf(c, "%s unable to remove sandbox %d from search: %v", desc, st.Id, err)
+	}
+
+	s := &Sandbox{st.Id, t.Id, u.Id, []int64{}, []int64{}, []int64{}, []int64{}, 0, time.Now()}
+	if _, err := datastore.Put(c, SandboxKeyById(c, s.Id), s); err != nil {
+		return nil, err
+	}
+	log.Infof(c, "%s tournament %d replayed by user %d in sandbox %d", desc, t.Id, u.Id, s.Id)
+	return s, nil
+}
+
+// Get pointer to a sandbox key given its id.
+func SandboxKeyById(c appengine.Context, id int64) *datastore.Key {
+	return datastore.NewKey(c, "Sandbox", "", id, nil)
+}
+
+// Get a sandbox given its id, the id of its tournament.
+func SandboxById(c appengine.Context, id int64) (*Sandbox, error) {
+	var s Sandbox
+	if err := datastore.Get(c, SandboxKeyById(c, id), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Get the sandboxes of a user, the most recent first.
+func SandboxesByUser(c appengine.Context, userId int64) []*Sandbox {
+	q := datastore.NewQuery("Sandbox").Filter("UserId =", userId)
+	var sandboxes []*Sandbox
+	if _, err := q.GetAll(c, &sandboxes); err != nil {
+		log.Errorf(c, "Sandboxes by user: error occurred during GetAll call: %v", err)
+		return nil
+	}
+	sort.Sort(SandboxByCreated(sandboxes))
+	return sandboxes
+}
+
+// SandboxByCreated implements sort.Interface for []*Sandbox, the most recent first.
+type SandboxByCreated []*Sandbox
+
+func (a SandboxByCreated) Len() int           { return len(a) }
+func (a SandboxByCreated) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a SandboxByCreated) Less(i, j int) bool { return a[i].Created.After(a[j].Created) }
+
+// Update a sandbox.
+func (s *Sandbox) Update(c appengine.Context) error {
+	_, err := datastore.Put(c, SandboxKeyById(c, s.Id), s)
+	return err
+}
+
+// Destroy a sandbox given its id.
+func DestroySandbox(c appengine.Context, id int64) error {
+	if err := datastore.Delete(c, SandboxKeyById(c, id)); err != nil && err != datastore.ErrNoSuchEntity {
+		return err
+	}
+	return nil
+}
+
+// Get the predict of the user for a revealed match of the sandbox.
+func (s *Sandbox) Predict(matchId int64) (result1, result2 int64, ok bool) {
+	for i, id := range s.MatchIds {
+		if id == matchId {
+			return s.Results1[i], s.Results2[i], true
+		}
+	}
+	return 0, 0, false
+}
+
+// Set the predict of the user for a match of the sandbox, then reveal the recorded result of the match.
+// It returns the points earned with the predict.
+// The match is revealed and the predict recorded in a transaction so a match is never scored twice.
+func (s *Sandbox) Play(c appengine.Context, t *Tournament, m *Tmatch, result1, result2 int64) (int64, error) {
+	if !m.Ready {
+		return 0, errors.New(fmt.Sprintf("match %d of sandbox %d cannot be predicted", m.IdNumber, s.Id))
+	}
+	source, err := TournamentById(c, s.TournamentId)
+	if err != nil {
+		return 0, err
+	}
+	recorded := GetMatchByIdNumber(c, *source, m.IdNumber)
+	if recorded == nil || !recorded.Finished {
+		return 0, errors.New(fmt.Sprintf("match %d of tournament %d has no result", m.IdNumber, source.Id))
+	}
+
+	var points int64
+	err = datastore.RunInTransaction(c, func(c appengine.Context) error {
+		var sandbox Sandbox
+		if err := datastore.Get(c, SandboxKeyById(c, s.Id), &sandbox); err != nil {
+			return err
+		}
+		var match Tmatch
+		if err := datastore.Get(c, MatchKeyById(c, m.Id), &match); err != nil {
+			return err
+		}
+		if _, _, played := sandbox.Predict(match.Id); played || match.Finished {
+			return errors.New(fmt.Sprintf("match %d of sandbox %d is already revealed", m.IdNumber, s.Id))
+		}
+
+		match.Result1 = recorded.Result1
+		match.Result2 = recorded.Result2
+		match.Finished = true
+		if _, err := datastore.Put(c, MatchKeyById(c, match.Id), &match); err != nil {
+			return err
+		}
+		points = computeScore(c, &match, &Predict{Result1: result1, Result2: result2})
+		sandbox.MatchIds = append(sandbox.MatchIds, match.Id)
+		sandbox.Results1 = append(sandbox.Results1, result1)
+		sandbox.Results2 = append(sandbox.Results2, result2)
+		sandbox.Points = append(sandbox.Points, points)
+		sandbox.Score += points
+		if _, err := datastore.Put(c, SandboxKeyById(c, sandbox.Id), &sandbox); err != nil {
+			return err
+		}
+		*s = sandbox
+		*m = match
+		return nil
+	}, &datastore.TransactionOptions{XG: true})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := t.reveal(c, m); err != nil {
+		return 0, err
+	}
+	// the next phase may have been set, its teams are the recorded ones.
+	if err := t.replayTeams(c, source); err != nil {
+		log.Errorf(c, "Sandbox.Play: unable to set the recorded teams of sandbox %d: %v", s.Id, err)
+	}
+	return points, nil
+}
+
+// Update the standings of the group of a revealed match of a sandbox and the next phase. Unlike SetResult
+// the participants and teams are not scored and no activity is published.
+func (t *Tournament) reveal(c appengine.Context, m *Tmatch) error {
+	if ismatch, g := t.IsMatchInGroup(c, m); ismatch {
+		if err := g.UpdateStandings(c); err != nil {
+			return err
+		}
+	}
+	if t.TwoLegged {
+		return nil
+	}
+	phases := MatchesGroupByPhase(t, GetAllMatchesFromTournament(c, t))
+	if completed, phaseId := phaseCompleted(m, phases); completed {
+		if int(phaseId+1) < len(phases) {
+			if _, err := UpdateNextPhase(c, t, &phases[phaseId], &phases[phaseId+1]); err != nil {
+				return err
+			}
+		}
+		if phaseId == 0 {
+			t.IsFirstStageComplete = true
+			return t.Update(c)
+		}
+	}
+	return nil
+}
+
+// Set the teams of the matches of a sandbox to the teams recorded in the replayed tournament, e.g. when
+// a knockout match was won on penalties by the second team.
+func (t *Tournament) replayTeams(c appengine.Context, source *Tournament) error {
+	ids := make(map[string]int64)
+	for id, name := range MapOfIdTeams(c, t) {
+		ids[name] = id
+	}
+	teamIds := make(map[int64]int64)
+	for id, name := range MapOfIdTeams(c, source) {
+		if sid, ok := ids[name]; ok {
+			teamIds[id] = sid
+		}
+	}
+	matches := replayedTeams(GetAllMatchesFromTournament(c, t), GetAllMatchesFromTournament(c, source), teamIds)
+	if len(matches) == 0 {
+		return nil
+	}
+	return UpdateMatches(c, matches)
+}
+
+// Get the matches ready to be predicted whose teams differ from the recorded ones, with the recorded teams set.
+// teamIds maps the team ids of the replayed tournament to the team ids of the sandbox.
+func replayedTeams(matches, recorded []*Tmatch, teamIds map[int64]int64) []*Tmatch {
+	mapRecorded := make(map[int64]*Tmatch)
+	for _, m := range recorded {
+		mapRecorded[m.IdNumber] = m
+	}
+	var changed []*Tmatch
+	for _, m := range matches {
+		if !m.Ready || m.Finished || len(strings.Split(m.Rule, " ")) == 2 {
+			continue
+		}
+		rm, ok := mapRecorded[m.IdNumber]
+		if !ok {
+			continue
+		}
+		teamId1, ok1 := teamIds[rm.TeamId1]
+		teamId2, ok2 := teamIds[rm.TeamId2]
+		if !ok1 || !ok2 || (m.TeamId1 == teamId1 && m.TeamId2 == teamId2) {
+			continue
+		}
+		m.TeamId1 = teamId1
+		m.TeamId2 = teamId2
+		changed = append(changed, m)
+	}
+	return changed
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+)
+
+func TestReplayedTeams(t *testing.T) {
+	recorded := []*Tmatch{
+		{IdNumber: 49, TeamId1: 1, TeamId2: 2, Finished: true},
+		{IdNumber: 57, TeamId1: 3, TeamId2: 2, Finished: true}, // 2 won match 49 on penalties.
+		{IdNumber: 58, TeamId1: 4, TeamId2: 5, Finished: true},
+	}
+	teamIds := map[int64]int64{1: 11, 2: 12, 3: 13, 4: 14, 5: 15}
+	matches := []*Tmatch{
+		{IdNumber: 49, TeamId1: 11, TeamId2: 12, Ready: true, Finished: true},
+		{IdNumber: 57, TeamId1: 13, TeamId2: 11, Ready: true},
+		{IdNumber: 58, Rule: "W51 W52"},
+	}
+
+	changed := replayedTeams(matches, recorded, teamIds)
+	if len(changed) != 1 || changed[0].IdNumber != 57 {
+		t.Fatalf("changed matches: got %v wanted match 57", changed)
+	}
+	if changed[0].TeamId1 != 13 || changed[0].TeamId2 != 12 {
+		t.Errorf("teams of match 57: got %d - %d wanted 13 - 12", changed[0].TeamId1, changed[0].TeamId2)
+	}
+}
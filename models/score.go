@@ -33,10 +33,14 @@ import (
 //        If prediction matches the trend you get a +1
 //        If the prediction does not match the match result you get +0.
 type Score struct {
-	Id           int64
-	UserId       int64
-	TournamentId int64
-	Scores       []int64
+	Id             int64
+	UserId         int64
+	TournamentId   int64
+	Scores         []int64
+	Bonuses        []int64 // rarity bonus earned in each predicted match, part of the accuracy of the teams that enabled it.
+	SidePoints     []int64 // side market points earned in each match, part of the tournament score.
+	QuestionIds    []int64 // ids of the questions already credited in the score.
+	QuestionPoints []int64 // points earned in each question, aligned with QuestionIds and part of the tournament score.
 }
 
 // ScoreOverall is a placeholder for the overall score of a user in different tournaments.
@@ -46,14 +50,19 @@ type ScoreOverall struct {
 	TournamentId    int64
 	Score           int64
 	LastProgression int64
+	Bonus           int64 // rarity bonus earned in the tournament.
 }
 
 // The Json version
 type ScoreJson struct {
-	Id           *int64   `json:",omitempty"`
-	UserId       *int64   `json:",omitempty"`
-	TournamentId *int64   `json:",omitempty"`
-	Scores       *[]int64 `json:",omitempty"`
+	Id             *int64   `json:",omitempty"`
+	UserId         *int64   `json:",omitempty"`
+	TournamentId   *int64   `json:",omitempty"`
+	Scores         *[]int64 `json:",omitempty"`
+	Bonuses        *[]int64 `json:",omitempty"`
+	SidePoints     *[]int64 `json:",omitempty"`
+	QuestionIds    *[]int64 `json:",omitempty"`
+	QuestionPoints *[]int64 `json:",omitempty"`
 }
 
 // Create a Score entity.
@@ -64,7 +73,11 @@ func CreateScore(c appengine.Context, userId int64, tournamentId int64) (*Score,
 	}
 	key := datastore.NewKey(c, "Score", "", sId, nil)
 	scores := make([]int64, 0)
-	s := &Score{sId, userId, tournamentId, scores}
+	bonuses := make([]int64, 0)
+	sides := make([]int64, 0)
+	questionIds := make([]int64, 0)
+	questionPoints := make([]int64, 0)
+	s := &Score{sId, userId, tournamentId, scores, bonuses, sides, questionIds, questionPoints}
 	if _, err = datastore.Put(c, key, s); err != nil {
 		return nil, err
 	}
@@ -84,7 +97,11 @@ func CreateScores(c appengine.Context, userIds []int64, tournamentId int64) ([]*
 		keys = append(keys, k)
 
 		scores := make([]int64, 0)
-		s := &Score{sId, id, tournamentId, scores}
+		bonuses := make([]int64, 0)
+		sides := make([]int64, 0)
+		questionIds := make([]int64, 0)
+		questionPoints := make([]int64, 0)
+		s := &Score{sId, id, tournamentId, scores, bonuses, sides, questionIds, questionPoints}
 		scoreEntities = append(scoreEntities, s)
 	}
 
@@ -108,12 +125,18 @@ func (s *Score) Add(c appengine.Context, score int64) error {
 	return s.Update(c)
 }
 
-// Add new scores to each score entity and update all scores at the end.
-func AddScores(c appengine.Context, tournamentScores []*Score, scores []int64) error {
+// Add new scores, rarity bonuses and side points to each score entity and update all scores at the end.
+func AddScores(c appengine.Context, tournamentScores []*Score, scores []int64, bonuses []int64, sides []int64) error {
 	scoresToUpdate := make([]*Score, 0)
 	for i, _ := range tournamentScores {
 		if tournamentScores[i] != nil {
 			tournamentScores[i].Scores = append(tournamentScores[i].Scores, scores[i])
+			if i < len(bonuses) {
+				tournamentScores[i].Bonuses = append(tournamentScores[i].Bonuses, bonuses[i])
+			}
+			if i < len(sides) {
+				tournamentScores[i].SidePoints = append(tournamentScores[i].SidePoints, sides[i])
+			}
 			scoresToUpdate = append(scoresToUpdate, tournamentScores[i])
 		}
 	}
@@ -124,6 +147,21 @@ func AddScores(c appengine.Context, tournamentScores []*Score, scores []int64) e
 
 }
 
+// Total score of the tournament: the points of the predicts, the side points and the points of the questions.
+func (s *Score) Total() int64 {
+	return sumInt64(&s.Scores) + sumInt64(&s.SidePoints) + sumInt64(&s.QuestionPoints)
+}
+
+// Check if the points of a question were already credited in the score.
+func (s *Score) HasQuestion(questionId int64) bool {
+	for _, id := range s.QuestionIds {
+		if id == questionId {
+			return true
+		}
+	}
+	return false
+}
+
 // Update an array of scores.
 func UpdateScores(c appengine.Context, scores []*Score) error {
 	keys := make([]*datastore.Key, len(scores))
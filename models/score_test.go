@@ -0,0 +1,34 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+package models
+
+import "testing"
+
+func TestScoreTotal(t *testing.T) {
+	s := Score{
+		Scores:         []int64{3, 1, 0},
+		Bonuses:        []int64{2, 0, 0},
+		SidePoints:     []int64{1, 0, 2},
+		QuestionIds:    []int64{10},
+		QuestionPoints: []int64{5},
+	}
+	if got := s.Total(); got != 12 {
+		t.Errorf("Total: got %d wanted %d", got, 12)
+	}
+	if !s.HasQuestion(10) || s.HasQuestion(11) {
+		t.Errorf("HasQuestion: got %v %v wanted true false", s.HasQuestion(10), s.HasQuestion(11))
+	}
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"sort"
+
+	"appengine"
+	"appengine/datastore"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// A Stats entity holds the prediction statistics of a user.
+//
+// It is updated each time a match predicted by the user is finished so that
+// statistics never require to go through all the predicts of the user.
+type Stats struct {
+	Id             int64
+	UserId         int64
+	Predicts       int64       // number of finished matches predicted by the user.
+	Exacts         int64       // number of predicts with the exact score.
+	Trends         int64       // number of predicts with the right trend but not the exact score.
+	CurrentStreak  int64       // number of consecutive predicts earning points, up to the last finished match.
+	LongestStreak  int64       // longest number of consecutive predicts earning points.
+	Wins1          int64       // number of predicts with first team as winner.
+	Draws          int64       // number of predicts with a draw.
+	Wins2          int64       // number of predicts with second team as winner.
+	PredictedGoals int64       // sum of goals of the predicts.
+	ActualGoals    int64       // sum of goals of the predicted matches.
+	Scorelines     []Scoreline // predicted scorelines.
+	MatchIds       []int64     // ids of the matches already taken into account.
+}
+
+// Create a Stats entity for a user.
+func CreateStats(c appengine.Context, userId int64) (*Stats, error) {
+	sId, _, err := datastore.AllocateIDs(c, "Stats", nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	key := datastore.NewKey(c, "Stats", "", sId, nil)
+	s := &Stats{Id: sId, UserId: userId, Scorelines: make([]Scoreline, 0), MatchIds: make([]int64, 0)}
+	if _, err = datastore.Put(c, key, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get a Stats key given an id.
+func StatsKeyById(c appengine.Context, id int64) *datastore.Key {
+	return datastore.NewKey(c, "Stats", "", id, nil)
+}
+
+// Update a Stats entity.
+func (s *Stats) Update(c appengine.Context) error {
+	k := StatsKeyById(c, s.Id)
+	old := new(Stats)
+	if err := datastore.Get(c, k, old); err == nil {
+		if _, err = datastore.Put(c, k, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search for the Stats entity of a user.
+func StatsByUser(c appengine.Context, userId int64) *Stats {
+	q := datastore.NewQuery("Stats").Filter("UserId"+" =", userId)
+
+	var stats []*Stats
+	if _, err := q.GetAll(c, &stats); err != nil {
+		log.Errorf(c, "StatsByUser: error occurred during GetAll: %v", err)
+		return nil
+	}
+	if len(stats) == 0 {
+		return nil
+	}
+	return stats[0]
+}
+
+// Add a finished match and its predict to the statistics.
+// Returns false if the match was already taken into account.
+func (s *Stats) Add(m *Tmatch, p *Predict) bool {
+	for _, id := range s.MatchIds {
+		if id == m.Id {
+			return false
+		}
+	}
+	s.MatchIds = append(s.MatchIds, m.Id)
+	s.Predicts++
+
+	if (m.Result1 == p.Result1) && (m.Result2 == p.Result2) {
+		s.Exacts++
+		s.CurrentStreak++
+	} else if sign(m.Result1-m.Result2) == sign(p.Result1-p.Result2) {
+		s.Trends++
+		s.CurrentStreak++
+	} else {
+		s.CurrentStreak = 0
+	}
+	if s.CurrentStreak > s.LongestStreak {
+		s.LongestStreak = s.CurrentStreak
+	}
+
+	if p.Result1 > p.Result2 {
+		s.Wins1++
+	} else if p.Result1 < p.Result2 {
+		s.Wins2++
+	} else {
+		s.Draws++
+	}
+	s.PredictedGoals += p.Result1 + p.Result2
+	s.ActualGoals += m.Result1 + m.Result2
+
+	for i := range s.Scorelines {
+		if s.Scorelines[i].Result1 == p.Result1 && s.Scorelines[i].Result2 == p.Result2 {
+			s.Scorelines[i].Count++
+			return true
+		}
+	}
+	s.Scorelines = append(s.Scorelines, Scoreline{p.Result1, p.Result2, 1})
+	return true
+}
+
+// Percentage of predicts with the exact score.
+func (s *Stats) ExactRate() float64 {
+	if s.Predicts == 0 {
+		return 0
+	}
+	return 100 * float64(s.Exacts) / float64(s.Predicts)
+}
+
+// Percentage of predicts with the right trend but not the exact score.
+func (s *Stats) TrendRate() float64 {
+	if s.Predicts == 0 {
+		return 0
+	}
+	return 100 * float64(s.Trends) / float64(s.Predicts)
+}
+
+// Home bias of the predicts: percentage of predicts with first team as winner
+// minus percentage of predicts with second team as winner.
+func (s *Stats) HomeBias() float64 {
+	if s.Predicts == 0 {
+		return 0
+	}
+	return 100 * float64(s.Wins1-s.Wins2) / float64(s.Predicts)
+}
+
+// Over/under bias of the predicts: average number of predicted goals per match
+// minus average number of actual goals per match.
+func (s *Stats) OverUnderBias() float64 {
+	if s.Predicts == 0 {
+		return 0
+	}
+	return float64(s.PredictedGoals-s.ActualGoals) / float64(s.Predicts)
+}
+
+// Most predicted scoreline, nil if there are no predicts.
+func (s *Stats) FavouriteScoreline() *Scoreline {
+	if len(s.Scorelines) == 0 {
+		return nil
+	}
+	scorelines := make([]Scoreline, len(s.Scorelines))
+	copy(scorelines, s.Scorelines)
+	sort.Sort(ScorelineByCount(scorelines))
+	return &scorelines[0]
+}
+
+// Update the statistics of a user with respect to a finished match.
+// When the statistics do not exist yet, all finished matches predicted by the user are taken into account.
+func (u *User) UpdateStats(c appengine.Context, m *Tmatch) error {
+	predicts := Predicts(PredictsByIds(c, u.PredictIds))
+
+	s := StatsByUser(c, u.Id)
+	if s == nil {
+		var err error
+		if s, err = CreateStats(c, u.Id); err != nil {
+			return err
+		}
+		matchIds := make([]int64, len(predicts))
+		for i, p := range predicts {
+			matchIds[i] = p.MatchId
+		}
+		// go through finished matches in chronological order to compute streaks.
+		matches := Matches(c, matchIds)
+		sort.Sort(MatchByDate(matches))
+		for _, old := range matches {
+			if !old.Finished || old.Id == m.Id {
+				continue
+			}
+			if ok, i := predicts.ContainsMatchId(old.Id); ok {
+				s.Add(old, predicts[i])
+			}
+		}
+	}
+
+	if ok, i := predicts.ContainsMatchId(m.Id); ok {
+		s.Add(m, predicts[i])
+	}
+	return s.Update(c)
+}
+
+// Sort matches by date.
+type MatchByDate []*Tmatch
+
+func (a MatchByDate) Len() int           { return len(a) }
+func (a MatchByDate) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a MatchByDate) Less(i, j int) bool { return a[i].Date.Before(a[j].Date) }
+
+func sign(x int64) int64 {
+	if x > 0 {
+		return 1
+	} else if x < 0 {
+		return -1
+	}
+	return 0
+}
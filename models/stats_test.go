@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+)
+
+func TestStatsAdd(t *testing.T) {
+	s := &Stats{}
+	tests := []struct {
+		match   Tmatch
+		predict Predict
+		streak  int64
+	}{
+		{Tmatch{Id: 1, Result1: 2, Result2: 1}, Predict{Result1: 2, Result2: 1}, 1}, // exact
+		{Tmatch{Id: 2, Result1: 3, Result2: 0}, Predict{Result1: 1, Result2: 0}, 2}, // trend
+		{Tmatch{Id: 3, Result1: 0, Result2: 1}, Predict{Result1: 1, Result2: 1}, 0}, // wrong
+		{Tmatch{Id: 4, Result1: 1, Result2: 1}, Predict{Result1: 1, Result2: 1}, 1}, // exact draw
+	}
+	for _, test := range tests {
+		if !s.Add(&test.match, &test.predict) {
+			t.Errorf("Add(match %d): got false wanted true", test.match.Id)
+		}
+		if s.CurrentStreak != test.streak {
+			t.Errorf("Add(match %d): got streak %d wanted %d", test.match.Id, s.CurrentStreak, test.streak)
+		}
+	}
+	if s.Add(&tests[0].match, &tests[0].predict) {
+		t.Errorf("Add(match 1 again): got true wanted false")
+	}
+
+	if s.Predicts != 4 || s.Exacts != 2 || s.Trends != 1 || s.LongestStreak != 2 {
+		t.Errorf("stats: got %+v", *s)
+	}
+	if s.Wins1 != 2 || s.Draws != 2 || s.Wins2 != 0 {
+		t.Errorf("outcomes: got %d %d %d wanted 2 2 0", s.Wins1, s.Draws, s.Wins2)
+	}
+	if s.PredictedGoals != 8 || s.ActualGoals != 9 {
+		t.Errorf("goals: got %d predicted and %d actual wanted 8 and 9", s.PredictedGoals, s.ActualGoals)
+	}
+	if s.ExactRate() != 50 || s.HomeBias() != 50 || s.OverUnderBias() != -0.25 {
+		t.Errorf("rates: got %v %v %v", s.ExactRate(), s.HomeBias(), s.OverUnderBias())
+	}
+	if f := s.FavouriteScoreline(); f == nil || f.Count != 2 || f.Result1 != 1 || f.Result2 != 1 {
+		t.Errorf("favourite scoreline: got %v wanted 1 - 1 twice", f)
+	}
+}
@@ -50,22 +50,30 @@ type Team struct {
 	AccOfTournaments []AccOfTournament // ids of Accuracies for each tournament the team is participating on .
 	PriceIds         []int64           // ids of Prices <=> prices defined for each tournament the team participates.
 	MembersCount     int64             // number of members in team
+	LeaderId         int64             // id of the User at the top of the team ranking.
+	LeaderSince      time.Time         // date the current leader took the top of the team ranking.
+	RevealPredicts   bool              // members can see each other's predicts before matches are locked.
+	AutoPredictCut   int64             // percentage of the points of automatic predicts not taken into account in the team accuracy.
+	RarityBonusIds   []int64           // ids of Tournaments where the rarity bonus is part of the team accuracy.
 }
 
 type TeamJson struct {
-	Id            *int64             `json:",omitempty"`
-	KeyName       *string            `json:",omitempty"`
-	Name          *string            `json:",omitempty"`
-	Description   *string            `json:",omitempty"`
-	AdminIds      *[]int64           `json:",omitempty"`
-	Private       *bool              `json:",omitempty"`
-	Created       *time.Time         `json:",omitempty"`
-	UserIds       *[]int64           `json:",omitempty"`
-	TournamentIds *[]int64           `json:",omitempty"`
-	Accuracy      *float64           `json:",omitempty"`
-	AccuracyIds   *[]AccOfTournament `json:",omitempty"`
-	PriceIds      *[]int64           `json:",omitempty"`
-	MembersCount  *int64             `json:",omitempty"`
+	Id             *int64             `json:",omitempty"`
+	KeyName        *string            `json:",omitempty"`
+	Name           *string            `json:",omitempty"`
+	Description    *string            `json:",omitempty"`
+	AdminIds       *[]int64           `json:",omitempty"`
+	Private        *bool              `json:",omitempty"`
+	Created        *time.Time         `json:",omitempty"`
+	UserIds        *[]int64           `json:",omitempty"`
+	TournamentIds  *[]int64           `json:",omitempty"`
+	Accuracy       *float64           `json:",omitempty"`
+	AccuracyIds    *[]AccOfTournament `json:",omitempty"`
+	PriceIds       *[]int64           `json:",omitempty"`
+	MembersCount   *int64             `json:",omitempty"`
+	RevealPredicts *bool              `json:",omitempty"`
+	AutoPredictCut *int64             `json:",omitempty"`
+	RarityBonusIds *[]int64           `json:",omitempty"`
 }
 
 // Create a team given a name, an admin id and a private mode.
@@ -81,7 +89,7 @@ func CreateTeam(c appengine.Context, name string, description string, adminId in
 	admins[0] = adminId
 	emptyArray := make([]int64, 0)
 	emtpyArrayOfAccOfTournament := make([]AccOfTournament, 0)
-	team := &Team{teamId, helpers.TrimLower(name), name, description, admins, private, time.Now(), emptyArray, emptyArray, float64(0), emtpyArrayOfAccOfTournament, emptyArray, 0}
+	team := &Team{teamId, helpers.TrimLower(name), name, description, admins, private, time.Now(), emptyArray, emptyArray, float64(0), emtpyArrayOfAccOfTournament, emptyArray, 0, 0, time.Time{}, false, 0, emptyArray}
 
 	_, err = datastore.Put(c, key, team)
 	if err != nil {
@@ -595,6 +603,79 @@ func (t *Team) RankingByUser(c appengine.Context, limit int) []*User {
 	}
 }
 
+// Check if the rarity bonus is part of the team accuracy in a tournament.
+func (t *Team) HasRarityBonus(tournamentId int64) bool {
+	for _, id := range t.RarityBonusIds {
+		if id == tournamentId {
+			return true
+		}
+	}
+	return false
+}
+
+// Enable or disable the rarity bonus of the team in a tournament.
+func (t *Team) SetRarityBonus(c appengine.Context, tournamentId int64, enabled bool) error {
+	ids := make([]int64, 0)
+	for _, id := range t.RarityBonusIds {
+		if id != tournamentId {
+			ids = append(ids, id)
+		}
+	}
+	if enabled {
+		ids = append(ids, tournamentId)
+	}
+	t.RarityBonusIds = ids
+	return t.Update(c)
+}
+
+// Get the member of the team with the highest score.
+func (t *Team) Leader(c appengine.Context) *User {
+	var leader *User
+	for _, u := range t.Players(c) {
+		if leader == nil || u.Score > leader.Score {
+			leader = u
+		}
+	}
+	return leader
+}
+
+// Track the leader of the team: the date a user takes the top of the ranking is kept to know
+// for how long he has been leading the team.
+func (t *Team) UpdateLeader(c appengine.Context) error {
+	leaderId := int64(0)
+	if leader := t.Leader(c); leader != nil {
+		leaderId = leader.Id
+	}
+	if leaderId == t.LeaderId {
+		return nil
+	}
+	t.LeaderId = leaderId
+	t.LeaderSince = time.Now()
+	return t.Update(c)
+}
+
+// Track the leaders of the teams of users whose scores changed.
+func UpdateTeamsLeader(c appengine.Context, users []*User) error {
+	done := make(map[int64]bool)
+	for _, u := range users {
+		for _, id := range u.TeamIds {
+			if done[id] {
+				continue
+			}
+			done[id] = true
+			team, err := TeamById(c, id)
+			if err != nil {
+				log.Errorf(c, "Update teams leader: team %v not found: %v", id, err)
+				continue
+			}
+			if err := team.UpdateLeader(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Sort teams by score
 type TeamByAccuracy []*Team
 
@@ -775,6 +856,8 @@ func (t *Team) AccuracyByTournament(c appengine.Context, tour *Tournament) *Accu
 				prog.Value = cur
 				a.Progression[i] = prog
 			}
+			a.Points = acc.Points
+			a.Members = acc.MembersAccuracy(c)
 			return &a
 		}
 	}
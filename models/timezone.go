@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"appengine"
+)
+
+// Date format of the matches of the tournament builders.
+const matchDateFormat = "Jan/02/2006"
+
+// Check if a timezone is a valid IANA timezone name, e.g. Europe/Paris.
+func IsTimezoneValid(tz string) bool {
+	if len(tz) == 0 || tz == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
+
+// Get the location of a timezone, UTC if the timezone is not valid.
+func timezoneLocation(tz string) *time.Location {
+	if !IsTimezoneValid(tz) {
+		return time.UTC
+	}
+	loc, _ := time.LoadLocation(tz)
+	return loc
+}
+
+// Get the location of the preferred timezone of the user, UTC by default.
+func (u *User) Location() *time.Location {
+	return timezoneLocation(u.Timezone)
+}
+
+// Get the city of a venue, a venue is either a city or a stadium followed by its city: "Arena da Baixada, Curitiba".
+func venueCity(venue string) string {
+	parts := strings.Split(venue, ",")
+	return strings.TrimSpace(parts[len(parts)-1])
+}
+
+// Parse the date of a match of a tournament builder in the timezone of its venue.
+func parseMatchDate(tb TournamentBuilder, date string, venue string) time.Time {
+	loc := time.UTC
+	if v := venueOf(tb.ArrayOfVenues(), venue); v != nil {
+		loc = timezoneLocation(v.Timezone)
+	}
+	d, _ := time.ParseInLocation(matchDateFormat, date, loc)
+	return d
+}
+
+// Get the location of each venue of the tournament by venue id.
+func (t *Tournament) VenueLocations(c appengine.Context) map[int64]*time.Location {
+	locs := make(map[int64]*time.Location)
+	for _, v := range Venues(c, t.VenueIds) {
+		locs[v.Id] = timezoneLocation(v.Timezone)
+	}
+	return locs
+}
+
+// Get the calendar day of a match at its venue, at midnight UTC. The dates of the tournament builders have no
+// kickoff time, a match is set at midnight of its day at its venue, so its day is taken in the timezone of the venue.
+// The location of the venue is UTC when it is nil.
+func MatchDay(date time.Time, venueLoc *time.Location) time.Time {
+	if venueLoc == nil {
+		venueLoc = time.UTC
+	}
+	y, m, d := date.In(venueLoc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
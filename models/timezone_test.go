@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsTimezoneValid(t *testing.T) {
+	tests := []struct {
+		tz   string
+		want bool
+	}{
+		{"Europe/Paris", true},
+		{"America/Sao_Paulo", true},
+		{"UTC", true},
+		{"", false},
+		{"Local", false},
+		{"Mars/Olympus_Mons", false},
+	}
+	for _, test := range tests {
+		if got := IsTimezoneValid(test.tz); got != test.want {
+			t.Errorf("IsTimezoneValid(%q): got %v wanted %v", test.tz, got, test.want)
+		}
+	}
+}
+
+func TestMatchDay(t *testing.T) {
+	saoPaulo, _ := time.LoadLocation("America/Sao_Paulo")
+	want := time.Date(2014, time.June, 12, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		date     time.Time
+		venueLoc *time.Location
+	}{
+		{"Midnight at the venue", time.Date(2014, time.June, 12, 3, 0, 0, 0, time.UTC), saoPaulo},
+		{"Evening at the venue", time.Date(2014, time.June, 13, 1, 0, 0, 0, time.UTC), saoPaulo},
+		{"Unknown venue", time.Date(2014, time.June, 12, 0, 0, 0, 0, time.UTC), nil},
+	}
+	for _, test := range tests {
+		if got := MatchDay(test.date, test.venueLoc); !got.Equal(want) {
+			t.Errorf("MatchDay(%q): got %v wanted %v", test.name, got, want)
+		}
+	}
+}
+
+func TestParseMatchDate(t *testing.T) {
+	wct := WorldCupTournament{}
+	tests := []struct {
+		venue string
+		want  time.Time
+	}{
+		{"Arena de São Paulo, São Paulo", time.Date(2014, time.June, 12, 3, 0, 0, 0, time.UTC)},
+		{"Manaus", time.Date(2014, time.June, 12, 4, 0, 0, 0, time.UTC)},
+		{"Unknown stadium", time.Date(2014, time.June, 12, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		if got := parseMatchDate(wct, "Jun/12/2014", test.venue); !got.Equal(test.want) {
+			t.Errorf("parseMatchDate(%q): got %v wanted %v", test.venue, got.UTC(), test.want)
+		}
+	}
+}
@@ -20,7 +20,6 @@ import (
 	"errors"
 	"fmt"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
@@ -48,6 +47,16 @@ type Tournament struct {
 	TwoLegged            bool
 	IsFirstStageComplete bool
 	Official             bool
+	LockPolicy           string   // deadline policy of the predictions: match, matchday, phase or tournament.
+	LockOffset           int64    // number of minutes before kickoff the predictions are locked.
+	Markets              []string // side markets switched on by the admins of the tournament.
+	GoalsLine            float64  // goals line of the over/under market.
+	Wagering             bool     // participants stake virtual credits on the outcome of the matches.
+	InitialCredits       int64    // credits given to each participant in wagering mode.
+	VenueIds             []int64  // ids of the venues where the matches take place.
+	Format               string   // builder of the tournament: worldcup or championsleague, deduced from the name if empty.
+	Archived             bool     // the tournament is finished and read-only, its rankings are frozen in its final standings.
+	ReplayOf             int64    // id of the tournament replayed in this practice sandbox, 0 if it is not a sandbox.
 }
 
 type TournamentJson struct {
@@ -67,6 +76,16 @@ type TournamentJson struct {
 	TwoLegged            *bool      `json:",omitempty"`
 	IsFirstStageComplete *bool      `json:",omitempty"`
 	Official             *bool      `json:",omitempty"`
+	LockPolicy           *string    `json:",omitempty"`
+	LockOffset           *int64     `json:",omitempty"`
+	Markets              *[]string  `json:",omitempty"`
+	GoalsLine            *float64   `json:",omitempty"`
+	Wagering             *bool      `json:",omitempty"`
+	InitialCredits       *int64     `json:",omitempty"`
+	VenueIds             *[]int64   `json:",omitempty"`
+	Format               *string    `json:",omitempty"`
+	Archived             *bool      `json:",omitempty"`
+	ReplayOf             *int64     `json:",omitempty"`
 }
 
 type TournamentBuilder interface {
@@ -76,6 +95,7 @@ type TournamentBuilder interface {
 	MapOfGroupMatches() map[string][][]string
 	MapOf2ndRoundMatches() map[string][][]string
 	MapOfPhaseIntervals() map[string][]int64
+	ArrayOfVenues() []Venue
 	MapOfIdTeams(c appengine.Context, tournament *Tournament) map[int64]string
 }
 
@@ -96,7 +116,7 @@ func CreateTournament(c appengine.Context, name string, description string, star
 	twoLegged := false
 	official := false
 
-	tournament := &Tournament{tournamentID, helpers.TrimLower(name), name, description, start, end, admins, time.Now(), emptyArray, emptyArray, emptyArray, emptyArray, emptyArray, twoLegged, false, official}
+	tournament := &Tournament{tournamentID, helpers.TrimLower(name), name, description, start, end, admins, time.Now(), emptyArray, emptyArray, emptyArray, emptyArray, emptyArray, twoLegged, false, official, LockPolicyMatch, 0, []string{}, defaultGoalsLine, false, defaultInitialCredits, emptyArray, "", false, 0}
 
 	_, err = datastore.Put(c, key, tournament)
 	if err != nil {
@@ -129,7 +149,17 @@ func FindTournaments(c appengine.Context, filter string, value interface{}) []*T
 	q := datastore.NewQuery("Tournament").Filter(filter+" =", value)
 	var tournaments []*Tournament
 	if _, err := q.GetAll(c, &tournaments); err == nil {
-		return tournaments
+		// sandboxes are private copies of tournaments, they are never found.
+		found := make([]*Tournament, 0)
+		for _, t := range tournaments {
+			if !t.IsSandbox() {
+				found = append(found, t)
+			}
+		}
+		if len(found) == 0 {
+			return nil
+		}
+		return found
 	} else {
 		log.Errorf(c, " Tournament.Find, error occurred during GetAll: %v", err)
 		return nil
@@ -176,10 +206,17 @@ func (t *Tournament) Update(c appengine.Context) error {
 func FindAllTournaments(c appengine.Context, count, page int64) []*Tournament {
 	desc := "tournament.FindAllTournaments"
 	q := datastore.NewQuery("Tournament")
-	var tournaments []*Tournament
-	if _, err := q.GetAll(c, &tournaments); err != nil {
+	var all []*Tournament
+	if _, err := q.GetAll(c, &all); err != nil {
 		log.Errorf(c, "%s error occurred during GetAll call: %v", desc, err)
 	}
+	// sandboxes are private to their user.
+	var tournaments []*Tournament
+	for _, t := range all {
+		if !t.IsSandbox() {
+			tournaments = append(tournaments, t)
+		}
+	}
 
 	// loop backward on all of these ids to fetch the teams
 	log.Infof(c, "%s calculateStartAndEnd(%v, %v, %v)", desc, int64(len(tournaments)), count, page)
@@ -212,6 +249,14 @@ func TournamentsByIds(c appengine.Context, ids []int64) []*Tournament {
 func (t *Tournament) Joined(c appengine.Context, u *User) bool {
 	// change in contains
 	hasTournament, _ := u.ContainsTournamentId(t.Id)
+	if !hasTournament && t.Archived {
+		// the archived tournaments of a user are moved to his archived list.
+		for _, id := range u.ArchivedTournamentIds {
+			if id == t.Id {
+				return true
+			}
+		}
+	}
 	return hasTournament
 }
 
@@ -358,54 +403,35 @@ func GetWordFrequencyForTournament(c appengine.Context, id int64, word string) i
 	return 0
 }
 
-// Reset tournament values: Points, GoalsF, GoalsA to zero.
+// Reset tournament values: Points, GoalsF, GoalsA to zero, the matches and the phases as they were when
+// the tournament was created: no result, open to predictions and the teams of the next phases not known yet.
 func (t *Tournament) Reset(c appengine.Context) error {
 	groups := Groups(c, t.GroupIds)
 	for _, g := range groups {
 		g.Points = make([]int64, len(g.Teams))
 		g.GoalsF = make([]int64, len(g.Teams))
 		g.GoalsA = make([]int64, len(g.Teams))
-		for _, m := range g.Matches {
-			m.Result1 = 0
-			m.Result2 = 0
-			if err := UpdateMatch(c, &m); err != nil {
-				return err
-			}
+		for i := range g.Matches {
+			g.Matches[i] = resetMatch(g.Matches[i], "")
 		}
 		if err := UpdateGroup(c, g); err != nil {
 			return err
 		}
 	}
-	// reset all match rules
-	tb := GetTournamentBuilder(t)
-
-	mapMatches2ndRound := tb.MapOf2ndRoundMatches()
-
-	const (
-		cMatchId       = 0
-		cMatchDate     = 1
-		cMatchTeam1    = 2
-		cMatchTeam2    = 3
-		cMatchLocation = 4
-	)
-
-	// build matches 2nd phase
-	const shortForm = "Jan/02/2006"
-	for _, roundMatches := range mapMatches2ndRound {
-		for _, matchData := range roundMatches {
-			matchInternalId, _ := strconv.Atoi(matchData[cMatchId])
-			m := GetMatchByIdNumber(c, *t, int64(matchInternalId))
-			rule := fmt.Sprintf("%s %s", matchData[cMatchTeam1], matchData[cMatchTeam2])
-			m.Rule = rule
-			m.Result1 = 0
-			m.Result2 = 0
-			if err := UpdateMatch(c, m); err != nil {
-				log.Errorf(c, "Reset: unable to reset rule on match: %v", err)
-				return err
-			}
-		}
+
+	// reset all matches, the 2nd stage ones get back the rules of the tournament builder.
+	rules := t.initialRules()
+	matches := append(Matches(c, t.Matches1stStage), Matches(c, t.Matches2ndStage)...)
+	for _, m := range matches {
+		*m = resetMatch(*m, rules[m.IdNumber])
 	}
-	return nil
+	if err := UpdateMatches(c, matches); err != nil {
+		log.Errorf(c, "Reset: unable to reset matches: %v", err)
+		return err
+	}
+
+	t.IsFirstStageComplete = false
+	return t.Update(c)
 }
 
 // From a tournament returns an array of the users that participate in it.
@@ -545,7 +571,26 @@ func (t *Tournament) RankingByUser(c appengine.Context, limit int) []*User {
 		users[i].Score = u.ScoreByTournament(c, t.Id)
 	}
 
-	sort.Sort(UserByScore(users))
+	// ties are broken by submission time of the predicts, loaded in batches for all the participants.
+	var predictIds []int64
+	for _, u := range users {
+		predictIds = append(predictIds, u.PredictIds...)
+	}
+	var predicts []*Predict
+	for low := 0; low < len(predictIds); low += predictsBatchSize {
+		high := low + predictsBatchSize
+		if high > len(predictIds) {
+			high = len(predictIds)
+		}
+		batch, err := PredictsByIds2(c, predictIds[low:high])
+		if err != nil {
+			log.Errorf(c, "RankingByUser: unable to get predicts: %v", err)
+			continue
+		}
+		predicts = append(predicts, batch...)
+	}
+	matchIds := append(append([]int64{}, t.Matches1stStage...), t.Matches2ndStage...)
+	sort.Sort(UserByScoreAndSubmission{users, lastSubmissions(predicts, matchIds)})
 
 	if len(users) <= limit {
 		return users
@@ -600,25 +645,17 @@ func (t *Tournament) Entity() ActivityEntity {
 	return ActivityEntity{Id: t.Id, Type: "tournament", DisplayName: t.Name}
 }
 
-// The progression is a number between 0 and 1 with the progression of the tournament
-// with respect of todays date and start and end date of tournament.
-func (t *Tournament) Progress(c appengine.Context) float64 {
-	now := time.Now()
-	if now.Before(t.Start) {
-		return float64(0)
-	}
-	if now.After(t.End) {
-		return float64(1)
+// Get the competition of a tournament, tournaments of the same competition share the same builder.
+func (t *Tournament) Competition() string {
+	if _, ok := GetTournamentBuilder(t).(WorldCupTournament); ok {
+		return CompetitionWorldCup
 	}
-	d := t.Start.Sub(now)
-	dt := t.Start.Sub(t.End)
-	log.Infof(c, "ratio: %v", d.Seconds()/dt.Seconds())
-	return d.Seconds() / dt.Seconds()
+	return CompetitionChampionsLeague
 }
 
 func GetTournamentBuilder(t *Tournament) TournamentBuilder {
 	var tb TournamentBuilder
-	if t.Name == "2014 FIFA World Cup" {
+	if t.Format == CompetitionWorldCup || (len(t.Format) == 0 && t.Name == "2014 FIFA World Cup") {
 		wct := WorldCupTournament{}
 		tb = wct
 	} else {
@@ -0,0 +1,246 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"time"
+
+	"appengine"
+	"appengine/datastore"
+	"appengine/taskqueue"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// Delay between the last result of a tournament and its archival, results can still be corrected in the meantime.
+const archiveDelay = 24 * time.Hour
+
+// FinalStandings is the snapshot of the rankings of a tournament taken when it is archived.
+// It is keyed by the id of the tournament.
+type FinalStandings struct {
+	TournamentId int64
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Users        []FinalRank // users ranking, the winner first.
+	Teams        []FinalRank // teams ranking, the winner first.
+	Created      time.Time
+}
+
+// FinalRank holds the final rank of a user or a team in a tournament.
+type FinalRank struct {
+	Rank     int64
+	Id       int64   // id of the user or the team.
+	Name     string  // username or team name.
+	Score    int64   // score of the user in the tournament.
+	Accuracy float64 // accuracy of the team in the tournament.
+}
+
+// Get pointer to the final standings key of a tournament.
+func FinalStandingsKey(c appengine.Context, tournamentId int64) *datastore.Key {
+	return datastore.NewKey(c, "FinalStandings", "", tournamentId, nil)
+}
+
+// Get the final standings of an archived tournament.
+func FinalStandingsByTournament(c appengine.Context, tournamentId int64) (*FinalStandings, error) {
+	var fs FinalStandings
+	if err := datastore.Get(c, FinalStandingsKey(c, tournamentId), &fs); err != nil {
+		return nil, err
+	}
+	return &fs, nil
+}
+
+// Destroy the final standings of a tournament.
+func DestroyFinalStandings(c appengine.Context, tournamentId int64) error {
+	if err := datastore.Delete(c, FinalStandingsKey(c, tournamentId)); err != nil && err != datastore.ErrNoSuchEntity {
+		return err
+	}
+	return nil
+}
+
+// Check if all the matches of a tournament are finished.
+func (t *Tournament) IsFinished(c appengine.Context) bool {
+	matches := GetAllMatchesFromTournament(c, t)
+	if len(matches) == 0 {
+		return false
+	}
+	for _, m := range matches {
+		if !m.Finished {
+			return false
+		}
+	}
+	return true
+}
+
+// Queue the archival of the tournament once all its matches are finished.
+func (t *Tournament) archiveWhenFinished(c appengine.Context) {
+	if t.Archived || !t.IsFinished(c) {
+		return
+	}
+	if err := t.QueueArchive(c, archiveDelay); err != nil {
+		log.Errorf(c, "Archive when finished: unable to queue archival of tournament %d: %v", t.Id, err)
+	}
+}
+
+// Queue the archival of the tournament after a delay.
+func (t *Tournament) QueueArchive(c appengine.Context, delay time.Duration) error {
+	desc := "Queue archive tournament:"
+	log.Infof(c, "%s Sending to taskqueue: archive tournament", desc)
+
+	b, errm := json.Marshal(t)
+	if errm != nil {
+		log.Errorf(c, "%s Error marshaling", desc, errm)
+	}
+
+	task := taskqueue.NewPOSTTask("/a/archive/tournament/", url.Values{
+		"tournament": []string{string(b)},
+	})
+	task.Delay = delay
+
+	if _, err := taskqueue.Add(c, task, "gw-queue"); err != nil {
+		log.Errorf(c, "%s unable to add task to taskqueue.", desc)
+		return err
+	}
+	log.Infof(c, "%s add task to taskqueue successfully", desc)
+	return nil
+}
+
+// Archive a finished tournament: the rankings are frozen into its final standings, the tournament and
+// the predicts of its participants are moved to their archived lists and the tournament becomes read-only.
+// Archiving an archived tournament does nothing. Each step can be run again, so an archival that failed
+// midway is completed by running it again: the final standings are only taken once and the users already
+// migrated are left as they are.
+func (t *Tournament) Archive(c appengine.Context) error {
+	desc := "Tournament.Archive:"
+	if t.Archived {
+		return nil
+	}
+
+	if _, err := FinalStandingsByTournament(c, t.Id); err == datastore.ErrNoSuchEntity {
+		users := t.RankingByUser(c, len(t.UserIds))
+		var teams []FinalRank
+		for _, team := range t.Teams(c) {
+			fr := FinalRank{Id: team.Id, Name: team.Name}
+			if acc, err := team.TournamentAcc(c, t); err == nil && len(acc.Accuracies) > 0 {
+				fr.Accuracy = acc.Accuracies[len(acc.Accuracies)-1]
+			}
+			teams = append(teams, fr)
+		}
+
+		fs := &FinalStandings{t.Id, t.Name, t.Start, t.End, finalUserRanks(users), finalTeamRanks(teams), time.Now()}
+		if _, err := datastore.Put(c, FinalStandingsKey(c, t.Id), fs); err != nil {
+			return err
+		}
+		log.Infof(c, "%s final standings of tournament %d saved", desc, t.Id)
+	} else if err != nil {
+		return err
+	}
+
+	matchIds := make(map[int64]bool)
+	for _, id := range append(append([]int64{}, t.Matches1stStage...), t.Matches2ndStage...) {
+		matchIds[id] = true
+	}
+	for _, u := range t.Participants(c) {
+		if err := u.archiveTournament(c, t.Id, matchIds); err != nil {
+			log.Errorf(c, "%s unable to archive tournament for user %d: %v", desc, u.Id, err)
+			return err
+		}
+	}
+
+	t.Archived = true
+	return t.Update(c)
+}
+
+// Move a tournament and the predicts of its matches to the archived lists of the user.
+// The user is read again in a transaction so that both lists are moved at once without losing concurrent updates.
+func (u *User) archiveTournament(c appengine.Context, tournamentId int64, matchIds map[int64]bool) error {
+	predictIds := make(map[int64]bool)
+	for low := 0; low < len(u.PredictIds); low += predictsBatchSize {
+		high := low + predictsBatchSize
+		if high > len(u.PredictIds) {
+			high = len(u.PredictIds)
+		}
+		predicts, err := PredictsByIds2(c, u.PredictIds[low:high])
+		if err != nil {
+			return err
+		}
+		for _, p := range predicts {
+			if matchIds[p.MatchId] {
+				predictIds[p.Id] = true
+			}
+		}
+	}
+
+	return datastore.RunInTransaction(c, func(c appengine.Context) error {
+		key := UserKeyById(c, u.Id)
+		var user User
+		if err := datastore.Get(c, key, &user); err != nil {
+			return err
+		}
+		user.PredictIds, user.ArchivedPredictInds = moveIds(user.PredictIds, user.ArchivedPredictInds, predictIds)
+		user.TournamentIds, user.ArchivedTournamentIds = moveIds(user.TournamentIds, user.ArchivedTournamentIds, map[int64]bool{tournamentId: true})
+		_, err := datastore.Put(c, key, &user)
+		return err
+	}, nil)
+}
+
+// Move the ids of a set from an array to another one, ids already moved are not duplicated.
+func moveIds(from, to []int64, ids map[int64]bool) ([]int64, []int64) {
+	kept := make([]int64, 0, len(from))
+	moved := append([]int64{}, to...)
+	present := make(map[int64]bool)
+	for _, id := range to {
+		present[id] = true
+	}
+	for _, id := range from {
+		if !ids[id] {
+			kept = append(kept, id)
+		} else if !present[id] {
+			moved = append(moved, id)
+			present[id] = true
+		}
+	}
+	return kept, moved
+}
+
+// Build the final ranks of users sorted by ascending score, the winner first.
+func finalUserRanks(users []*User) []FinalRank {
+	ranks := make([]FinalRank, len(users))
+	for i, u := range users {
+		r := len(users) - 1 - i
+		ranks[r] = FinalRank{Rank: int64(r + 1), Id: u.Id, Name: u.Username, Score: u.Score}
+	}
+	return ranks
+}
+
+// Sort the final ranks of teams by descending accuracy, the winner first.
+func finalTeamRanks(teams []FinalRank) []FinalRank {
+	sort.Stable(FinalRankByAccuracy(teams))
+	for i, _ := range teams {
+		teams[i].Rank = int64(i + 1)
+	}
+	return teams
+}
+
+type FinalRankByAccuracy []FinalRank
+
+func (a FinalRankByAccuracy) Len() int           { return len(a) }
+func (a FinalRankByAccuracy) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a FinalRankByAccuracy) Less(i, j int) bool { return a[i].Accuracy > a[j].Accuracy }
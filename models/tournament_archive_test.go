@@ -0,0 +1,50 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMoveIds(t *testing.T) {
+	from, to := moveIds([]int64{1, 2, 3, 4}, []int64{9, 3}, map[int64]bool{2: true, 3: true})
+	if !reflect.DeepEqual(from, []int64{1, 4}) {
+		t.Errorf("from: got %v wanted [1 4]", from)
+	}
+	if !reflect.DeepEqual(to, []int64{9, 3, 2}) {
+		t.Errorf("to: got %v wanted [9 3 2]", to)
+	}
+}
+
+func TestFinalRanks(t *testing.T) {
+	// users come sorted by ascending score from the tournament ranking.
+	users := []*User{{Id: 3, Username: "c", Score: 2}, {Id: 1, Username: "a", Score: 5}, {Id: 2, Username: "b", Score: 9}}
+	ranks := finalUserRanks(users)
+	for i, want := range []int64{2, 1, 3} {
+		if ranks[i].Id != want || ranks[i].Rank != int64(i+1) {
+			t.Errorf("user rank %d: got %+v wanted user %d", i+1, ranks[i], want)
+		}
+	}
+
+	teams := finalTeamRanks([]FinalRank{{Id: 1, Accuracy: 0.2}, {Id: 2, Accuracy: 0.7}, {Id: 3, Accuracy: 0.2}})
+	for i, want := range []int64{2, 1, 3} {
+		if teams[i].Id != want || teams[i].Rank != int64(i+1) {
+			t.Errorf("team rank %d: got %+v wanted team %d", i+1, teams[i], want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"appengine"
+)
+
+// A BracketTie is a match of the second stage of a tournament in its knockout bracket.
+type BracketTie struct {
+	Match *Tmatch
+	Phase string
+	Rule1 string        // where the first team comes from, e.g. 1A (first of group A) or W49 (winner of match 49).
+	Rule2 string        // where the second team comes from.
+	Ties  []*BracketTie // ties whose winners play this tie.
+}
+
+// Id of the team that goes through a finished knockout match, 0 otherwise.
+// As when the next phase is updated, the first team goes through on a draw.
+// The result is the one of a single match, the aggregate of a two-legged tie is not taken into account.
+func (m *Tmatch) Winner() int64 {
+	if !m.Finished {
+		return 0
+	}
+	if m.Result1 >= m.Result2 {
+		return m.TeamId1
+	}
+	return m.TeamId2
+}
+
+// Get the knockout bracket of a tournament.
+// It returns the ties no other tie depends on, the final first, each one with the tree of ties it comes from.
+//
+// Only single-leg formats whose matches refer to the winner of a previous match (e.g. W49) build a tree.
+// Formats with two-legged ties or with teams to be defined (e.g. TBD1 in the Champions League) give a flat
+// list of ties, and the winner of each tie is the winner of its leg.
+func (t *Tournament) Bracket(c appengine.Context) []*BracketTie {
+	tb := GetTournamentBuilder(t)
+
+	rules := make(map[int64][]string)
+	for _, round := range tb.MapOf2ndRoundMatches() {
+		for _, matchData := range round {
+			if id, err := strconv.ParseInt(matchData[cMatchId], 10, 64); err == nil {
+				rules[id] = []string{matchData[cMatchTeam1], matchData[cMatchTeam2]}
+			}
+		}
+	}
+	return buildBracket(Matches(c, t.Matches2ndStage), rules, tb.MapOfPhaseIntervals())
+}
+
+// Build the bracket of the matches of the second stage given the rules of each match by id number.
+func buildBracket(matches []*Tmatch, rules map[int64][]string, limits map[string][]int64) []*BracketTie {
+	ties := make(map[int64]*BracketTie)
+	ids := make([]int64, 0, len(matches))
+	for _, m := range matches {
+		tie := &BracketTie{Match: m}
+		for phase, l := range limits {
+			if m.IdNumber >= l[0] && m.IdNumber <= l[1] {
+				tie.Phase = phase
+			}
+		}
+		if r, ok := rules[m.IdNumber]; ok {
+			tie.Rule1, tie.Rule2 = r[0], r[1]
+		}
+		ties[m.IdNumber] = tie
+		ids = append(ids, m.IdNumber)
+	}
+	sort.Sort(int64Slice(ids))
+
+	isChild := make(map[int64]bool)
+	for _, id := range ids {
+		tie := ties[id]
+		for _, rule := range []string{tie.Rule1, tie.Rule2} {
+			if from, ok := winnerRule(rule); ok {
+				if child, ok := ties[from]; ok {
+					tie.Ties = append(tie.Ties, child)
+					isChild[from] = true
+				}
+			}
+		}
+	}
+
+	var roots []*BracketTie
+	for i := len(ids) - 1; i >= 0; i-- {
+		if !isChild[ids[i]] {
+			roots = append(roots, ties[ids[i]])
+		}
+	}
+	return roots
+}
+
+// Get the match id number of a rule refering to the winner of a match, like W49.
+func winnerRule(rule string) (int64, bool) {
+	if !strings.HasPrefix(rule, "W") {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(rule[1:], 10, 64)
+	return id, err == nil
+}
+
+// int64Slice type implements the sort.Interface for []int64 in increasing order.
+type int64Slice []int64
+
+func (a int64Slice) Len() int           { return len(a) }
+func (a int64Slice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a int64Slice) Less(i, j int) bool { return a[i] < a[j] }
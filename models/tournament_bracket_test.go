@@ -0,0 +1,50 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+)
+
+func TestBuildBracket(t *testing.T) {
+	matches := []*Tmatch{{IdNumber: 64}, {IdNumber: 61, TeamId1: 1, TeamId2: 2, Result1: 1, Result2: 2, Finished: true}, {IdNumber: 63}, {IdNumber: 62}}
+	rules := map[int64][]string{
+		61: {"W57", "W58"},
+		62: {"W59", "W60"},
+		63: {"L61", "L62"},
+		64: {"W61", "W62"},
+	}
+	limits := map[string][]int64{cSemiFinals: {61, 62}, cThirdPlace: {63, 63}, cFinals: {64, 64}}
+
+	roots := buildBracket(matches, rules, limits)
+	if len(roots) != 2 {
+		t.Fatalf("buildBracket: got %d roots wanted 2", len(roots))
+	}
+	final, third := roots[0], roots[1]
+	if final.Match.IdNumber != 64 || final.Phase != cFinals || final.Rule1 != "W61" || final.Rule2 != "W62" {
+		t.Errorf("buildBracket: got final %v %q %q %q", final.Match.IdNumber, final.Phase, final.Rule1, final.Rule2)
+	}
+	if third.Match.IdNumber != 63 || len(third.Ties) != 0 {
+		t.Errorf("buildBracket: got third place %v with %d ties", third.Match.IdNumber, len(third.Ties))
+	}
+	if len(final.Ties) != 2 || final.Ties[0].Match.IdNumber != 61 || final.Ties[1].Match.IdNumber != 62 {
+		t.Fatalf("buildBracket: wrong ties of final")
+	}
+	if semi := final.Ties[0]; semi.Phase != cSemiFinals || semi.Match.Winner() != 2 {
+		t.Errorf("buildBracket: got semi-final %q won by %d", semi.Phase, semi.Match.Winner())
+	}
+}
@@ -114,6 +114,21 @@ func (clt ChampionsLeagueTournament) ArrayOfPhases() []string {
 	return []string{cQuarterFinals, cSemiFinals, cFinals}
 }
 
+// Return an array of the venues of the champions league tournament.
+func (clt ChampionsLeagueTournament) ArrayOfVenues() []Venue {
+	return []Venue{
+		{0, "Juventus Stadium", "Turin", "Italy", "Europe/Rome", 41507, 45.1096, 7.6413},
+		{0, "Estádio do Dragão", "Porto", "Portugal", "Europe/Lisbon", 50033, 41.1618, -8.5839},
+		{0, "Parc des Princes", "Paris", "France", "Europe/Paris", 48583, 48.8414, 2.2530},
+		{0, "Stade Vicente-Calderón", "Madrid", "Spain", "Europe/Madrid", 54907, 40.4017, -3.7206},
+		{0, "Stade Santiago Bernabéu", "Madrid", "Spain", "Europe/Madrid", 81044, 40.4531, -3.6883},
+		{0, "Stade Louis-II", "Monaco", "Monaco", "Europe/Monaco", 18523, 43.7276, 7.4155},
+		{0, "Allianz Arena", "Munchen", "Germany", "Europe/Berlin", 75000, 48.2188, 11.6247},
+		{0, "Camp Nou", "Barcelona", "Spain", "Europe/Madrid", 99354, 41.3809, 2.1228},
+		{0, "Olympiastadion", "Berlin", "Germany", "Europe/Berlin", 74475, 52.5147, 13.2395},
+	}
+}
+
 // Build a map with key the corresponding phase in the champions league tournament
 // at value a tuple that represent the match number interval in which the phase take place:
 // Quarter-finals: matches 1 to 8
@@ -138,11 +153,20 @@ func CreateChampionsLeague(c appengine.Context, adminId int64) (*Tournament, err
 	// for date parsing
 	const shortForm = "Jan/02/2006"
 
+	clt := ChampionsLeagueTournament{}
+
+	// venues of the tournament, created first so that matches can reference them.
+	venues := clt.ArrayOfVenues()
+	venueIds, errv := CreateVenues(c, venues)
+	if errv != nil {
+		return nil, errv
+	}
+	log.Infof(c, "Champions League: venues: %v put in datastore ok", venueIds)
+
 	// mapMatches2ndRound  is a map where the key is a string which represent the rounds
 	// the key is a two dimensional string array. each element in the array represent a specific field in the match
 	// mapMatches2ndRound is a map[string][][]string
 	// example: "1", "Apr/14/2014", "Paris Saint-Germain", "AS Monaco FC", "Parc des Princes, Paris"}
-	clt := ChampionsLeagueTournament{}
 	clMatches2ndStage := clt.MapOf2ndRoundMatches()
 	clMapTeamCodes := clt.MapOfTeamCodes()
 
@@ -165,7 +189,7 @@ func CreateChampionsLeague(c appengine.Context, adminId int64) (*Tournament, err
 		teamkey := datastore.NewKey(c, "Tteam", "", teamID, nil)
 		log.Infof(c, "Champions League: team: %v NewKey ok", teamName)
 
-		team := &Tteam{teamID, teamName, teamCode}
+		team := &Tteam{teamID, teamName, teamCode, 0}
 		log.Infof(c, "Champions League: team: %v instance of team ok", teamName)
 
 		_, err := datastore.Put(c, teamkey, team)
@@ -191,7 +215,7 @@ func CreateChampionsLeague(c appengine.Context, adminId int64) (*Tournament, err
 		matchkey := datastore.NewKey(c, "Tmatch", "", matchID, nil)
 		log.Infof(c, "Champions League: match: new key ok")
 
-		matchTime, _ := time.Parse(shortForm, matchData[cMatchDate])
+		matchTime := parseMatchDate(clt, matchData[cMatchDate], matchData[cMatchLocation])
 		matchInternalId, _ := strconv.Atoi(matchData[cMatchId])
 
 		rule := fmt.Sprintf("%s %s", matchData[cMatchTeam1], matchData[cMatchTeam2])
@@ -209,6 +233,9 @@ func CreateChampionsLeague(c appengine.Context, adminId int64) (*Tournament, err
 			false,
 			true,
 			true,
+			time.Time{},
+			0,
+			venueIdOf(venues, matchData[cMatchLocation]),
 		}
 		log.Infof(c, "Champions League: match 2nd round: build match ok")
 
@@ -239,7 +266,7 @@ func CreateChampionsLeague(c appengine.Context, adminId int64) (*Tournament, err
 			matchkey := datastore.NewKey(c, "Tmatch", "", matchID, nil)
 			log.Infof(c, "Champions League: match: new key ok")
 
-			matchTime, _ := time.Parse(shortForm, matchData[cMatchDate])
+			matchTime := parseMatchDate(clt, matchData[cMatchDate], matchData[cMatchLocation])
 			matchInternalId, _ := strconv.Atoi(matchData[cMatchId])
 
 			rule := fmt.Sprintf("%s %s", matchData[cMatchTeam1], matchData[cMatchTeam2])
@@ -257,6 +284,9 @@ func CreateChampionsLeague(c appengine.Context, adminId int64) (*Tournament, err
 				false,
 				false,
 				true,
+				time.Time{},
+				0,
+				venueIdOf(venues, matchData[cMatchLocation]),
 			}
 			log.Infof(c, "Champions League: match 2nd round: build match ok")
 
@@ -287,6 +317,8 @@ func CreateChampionsLeague(c appengine.Context, adminId int64) (*Tournament, err
 		tournament.Matches2ndStage = matches2ndStageIds
 		tournament.UserIds = userIds
 		tournament.TeamIds = teamIds
+		tournament.VenueIds = venueIds
+		tournament.Format = CompetitionChampionsLeague
 		tournament.TwoLegged = false
 		tournament.IsFirstStageComplete = false
 		if err1 := tournament.Update(c); err1 != nil {
@@ -0,0 +1,260 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"appengine"
+	"appengine/datastore"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// Clone a tournament into a new tournament that starts at a new date, e.g. the next season of a competition.
+// The structure is copied: groups, phases, match rules, teams, venues and settings, the matches are shifted
+// to the new dates. Results, predicts and participants are not copied.
+func (t *Tournament) Clone(c appengine.Context, name string, start time.Time, adminId int64) (*Tournament, error) {
+	desc := "Tournament.Clone:"
+	offset := start.Sub(t.Start)
+
+	// venues
+	oldVenues := Venues(c, t.VenueIds)
+	venues := make([]Venue, len(oldVenues))
+	for i, v := range oldVenues {
+		venues[i] = *v
+	}
+	venueIds, err := CreateVenues(c, venues)
+	if err != nil {
+		return nil, err
+	}
+	mapVenueId := make(map[int64]int64)
+	for i, v := range oldVenues {
+		mapVenueId[v.Id] = venueIds[i]
+	}
+
+	// matches with the rules the builder starts the tournament with.
+	rules := t.initialRules()
+	matches1stStage := Matches(c, t.Matches1stStage)
+	matches2ndStage := Matches(c, t.Matches2ndStage)
+	var clones1stStage, clones2ndStage []*Tmatch
+	for _, m := range matches1stStage {
+		cm := cloneMatch(*m, "", offset)
+		clones1stStage = append(clones1stStage, &cm)
+	}
+	for _, m := range matches2ndStage {
+		rule, ok := rules[m.IdNumber]
+		if !ok {
+			rule = m.Rule
+		}
+		cm := cloneMatch(*m, rule, offset)
+		clones2ndStage = append(clones2ndStage, &cm)
+	}
+
+	// teams of the groups and of the matches that are known from the start.
+	groups := Groups(c, t.GroupIds)
+	var teamIds []int64
+	for _, g := range groups {
+		for _, team := range g.Teams {
+			teamIds = append(teamIds, team.Id)
+		}
+	}
+	var clones []*Tmatch
+	clones = append(clones, clones1stStage...)
+	clones = append(clones, clones2ndStage...)
+	for _, m := range clones {
+		teamIds = append(teamIds, m.TeamId1, m.TeamId2)
+	}
+	mapTeamId, err := cloneTteams(c, teamIds)
+	if err != nil {
+		return nil, err
+	}
+
+	// matches, by id of the match they are cloned from.
+	mapMatch := make(map[int64]*Tmatch)
+	if len(clones) > 0 {
+		low, _, err := datastore.AllocateIDs(c, "Tmatch", nil, len(clones))
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]*datastore.Key, len(clones))
+		for i, m := range clones {
+			mapMatch[m.Id] = m
+			m.Id = low + int64(i)
+			m.TeamId1 = mapTeamId[m.TeamId1]
+			m.TeamId2 = mapTeamId[m.TeamId2]
+			m.VenueId = mapVenueId[m.VenueId]
+			keys[i] = MatchKeyById(c, m.Id)
+		}
+		if _, err := datastore.PutMulti(c, keys, clones); err != nil {
+			return nil, err
+		}
+	}
+
+	// groups
+	groupIds := make([]int64, len(groups))
+	if len(groups) > 0 {
+		low, _, err := datastore.AllocateIDs(c, "Tgroup", nil, len(groups))
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]*datastore.Key, len(groups))
+		clonedGroups := make([]*Tgroup, len(groups))
+		for i, g := range groups {
+			groupIds[i] = low + int64(i)
+			keys[i] = GroupKeyById(c, groupIds[i])
+			cg := &Tgroup{groupIds[i], g.Name, make([]Tteam, len(g.Teams)), make([]Tmatch, 0), make([]int64, len(g.Teams)), make([]int64, len(g.Teams)), make([]int64, len(g.Teams))}
+			for j, team := range g.Teams {
+				cg.Teams[j] = Tteam{mapTeamId[team.Id], team.Name, team.Iso, team.Strength}
+			}
+			for _, m := range g.Matches {
+				if cm, ok := mapMatch[m.Id]; ok {
+					cg.Matches = append(cg.Matches, *cm)
+				}
+			}
+			clonedGroups[i] = cg
+		}
+		if _, err := datastore.PutMulti(c, keys, clonedGroups); err != nil {
+			return nil, err
+		}
+	}
+
+	end := t.End.Add(offset)
+	tournament, err := CreateTournament(c, name, t.Description, start, end, adminId)
+	if err != nil {
+		return nil, err
+	}
+	tournament.GroupIds = groupIds
+	tournament.Matches1stStage = matchIds(clones1stStage)
+	tournament.Matches2ndStage = matchIds(clones2ndStage)
+	tournament.VenueIds = venueIds
+	tournament.TwoLegged = t.TwoLegged
+	tournament.LockPolicy = t.LockPolicy
+	tournament.LockOffset = t.LockOffset
+	tournament.Markets = t.Markets
+	tournament.GoalsLine = t.GoalsLine
+	tournament.Wagering = t.Wagering
+	tournament.InitialCredits = t.InitialCredits
+	tournament.Format = t.Competition()
+	if err := tournament.Update(c); err != nil {
+		return nil, err
+	}
+	log.Infof(c, "%s tournament %d cloned into tournament %d", desc, t.Id, tournament.Id)
+	return tournament, nil
+}
+
+// Enroll the teams of a tournament into the current tournament: the members of the teams join it
+// and the descriptions of the prices of the teams are carried over.
+func (t *Tournament) CloneTeams(c appengine.Context, from *Tournament) error {
+	for _, team := range from.Teams(c) {
+		if err := t.TeamJoin(c, team); err != nil {
+			return err
+		}
+		old := team.PriceByTournament(c, from.Id)
+		if old == nil || len(old.Description) == 0 {
+			continue
+		}
+		if p := team.PriceByTournament(c, t.Id); p != nil {
+			p.Description = old.Description
+			if err := p.Update(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Copy a match shifted by an offset, without result. The teams of a match whose rule depends on
+// the previous phase, e.g. "W49 W50", are not known until that phase is complete.
+func cloneMatch(m Tmatch, rule string, offset time.Duration) Tmatch {
+	cm := resetMatch(m, rule)
+	cm.Date = m.Date.Add(offset)
+	if !m.LockAt.IsZero() {
+		cm.LockAt = m.LockAt.Add(offset)
+	}
+	return cm
+}
+
+// Get a match as it was when the tournament was created: no result, open to predictions and with the given rule.
+// The teams of a match whose rule names them, e.g. "1A 2B", are not known yet.
+func resetMatch(m Tmatch, rule string) Tmatch {
+	cm := m
+	cm.Rule = rule
+	cm.Result1 = 0
+	cm.Result2 = 0
+	cm.FirstScorer = 0
+	cm.Finished = false
+	cm.CanPredict = true
+	cm.Ready = true
+	if len(strings.Split(rule, " ")) == 2 {
+		cm.TeamId1 = 0
+		cm.TeamId2 = 0
+		cm.Ready = false
+	}
+	return cm
+}
+
+// Get the rules of the 2nd stage matches of a tournament as set by its builder, key: match id number.
+func (t *Tournament) initialRules() map[int64]string {
+	const (
+		cMatchId    = 0
+		cMatchTeam1 = 2
+		cMatchTeam2 = 3
+	)
+	rules := make(map[int64]string)
+	for _, roundMatches := range GetTournamentBuilder(t).MapOf2ndRoundMatches() {
+		for _, matchData := range roundMatches {
+			id, _ := strconv.ParseInt(matchData[cMatchId], 10, 64)
+			rules[id] = matchData[cMatchTeam1] + " " + matchData[cMatchTeam2]
+		}
+	}
+	return rules
+}
+
+// Copy the tournament teams (Tteam) of an array of ids, returns the map of the old ids to the new ones.
+func cloneTteams(c appengine.Context, ids []int64) (map[int64]int64, error) {
+	mapTeamId := make(map[int64]int64)
+	for _, id := range ids {
+		if _, ok := mapTeamId[id]; ok || id == 0 {
+			continue
+		}
+		team, err := TTeamById(c, id)
+		if err != nil {
+			return nil, err
+		}
+		teamId, _, err := datastore.AllocateIDs(c, "Tteam", nil, 1)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := datastore.Put(c, datastore.NewKey(c, "Tteam", "", teamId, nil), &Tteam{teamId, team.Name, team.Iso, team.Strength}); err != nil {
+			return nil, err
+		}
+		mapTeamId[id] = teamId
+	}
+	return mapTeamId, nil
+}
+
+// Get the ids of an array of matches.
+func matchIds(matches []*Tmatch) []int64 {
+	ids := make([]int64, len(matches))
+	for i, m := range matches {
+		ids[i] = m.Id
+	}
+	return ids
+}
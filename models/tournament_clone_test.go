@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCloneMatch(t *testing.T) {
+	date := time.Date(2014, time.June, 28, 17, 0, 0, 0, time.UTC)
+	offset := 365 * 24 * time.Hour
+	played := Tmatch{Id: 49, IdNumber: 49, Date: date, TeamId1: 1, TeamId2: 2, Result1: 1, Result2: 1, Finished: true, Ready: true, FirstScorer: 2, VenueId: 7}
+
+	knockout := cloneMatch(played, "1A 2B", offset)
+	if !knockout.Date.Equal(date.Add(offset)) {
+		t.Errorf("date: got %v wanted %v", knockout.Date, date.Add(offset))
+	}
+	if knockout.Result1 != 0 || knockout.Result2 != 0 || knockout.Finished || knockout.FirstScorer != 0 {
+		t.Errorf("result should be empty: %+v", knockout)
+	}
+	if knockout.TeamId1 != 0 || knockout.TeamId2 != 0 || knockout.Ready || knockout.Rule != "1A 2B" {
+		t.Errorf("teams should wait for the rule: %+v", knockout)
+	}
+	if knockout.VenueId != 7 || knockout.IdNumber != 49 {
+		t.Errorf("venue and id number should be kept: %+v", knockout)
+	}
+
+	group := cloneMatch(played, "", offset)
+	if group.TeamId1 != 1 || group.TeamId2 != 2 || !group.Ready || !group.CanPredict {
+		t.Errorf("teams should be kept: %+v", group)
+	}
+}
@@ -17,12 +17,9 @@
 package models
 
 import (
-	"math/rand"
-
 	"appengine"
 	"appengine/datastore"
 
-	"github.com/santiaago/gonawin/helpers"
 	"github.com/santiaago/gonawin/helpers/log"
 )
 
@@ -108,30 +105,6 @@ func DestroyGroups(c appengine.Context, groupIds []int64) error {
 	return nil
 }
 
-// Update points in group with result of match.
-func UpdatePointsAndGoals(c appengine.Context, g *Tgroup, m *Tmatch, tournament *Tournament) error {
-	for i, t := range g.Teams {
-		if t.Id == m.TeamId1 {
-			if m.Result1 > m.Result2 {
-				g.Points[i] += 3
-			} else if m.Result1 == m.Result2 {
-				g.Points[i] += 1
-			}
-			g.GoalsF[i] += m.Result1
-			g.GoalsA[i] += m.Result2
-		} else if t.Id == m.TeamId2 {
-			if m.Result2 > m.Result1 {
-				g.Points[i] += 3
-			} else if m.Result2 == m.Result1 {
-				g.Points[i] += 1
-			}
-			g.GoalsF[i] += m.Result2
-			g.GoalsA[i] += m.Result1
-		}
-	}
-	return nil
-}
-
 // Check if the match is part of a group phase in the current tournament.
 func (t *Tournament) IsMatchInGroup(c appengine.Context, m *Tmatch) (bool, *Tgroup) {
 	groups := Groups(c, t.GroupIds)
@@ -144,74 +117,3 @@ func (t *Tournament) IsMatchInGroup(c appengine.Context, m *Tmatch) (bool, *Tgro
 	}
 	return false, nil
 }
-
-// Get team with highest rank in group based on group points, goals for and goals against.
-func getFirstTeamInGroup(c appengine.Context, g *Tgroup) (*Tteam, int) {
-	points := make([]int64, len(g.Points))
-	copy(points, g.Points)
-
-	argmax1, max1 := helpers.ArgMaxInt64(points)
-	points[argmax1] = -1
-	argmax2, max2 := helpers.ArgMaxInt64(points)
-	if max1 == max2 { // points equal try by difference
-		diff := make([]int64, len(points))
-		for i, _ := range points {
-			diff[i] = g.GoalsF[i] - g.GoalsA[i]
-		}
-		if diff[argmax1] > diff[argmax2] {
-			return &g.Teams[argmax1], argmax1
-		} else if diff[argmax1] < diff[argmax2] {
-			return &g.Teams[argmax2], argmax2
-		} else { // diff equal try by greatest number of goals scored
-			if g.GoalsF[argmax1] > g.GoalsF[argmax2] {
-				return &g.Teams[argmax1], argmax1
-			} else if g.GoalsF[argmax1] < g.GoalsF[argmax2] {
-				return &g.Teams[argmax2], argmax2
-			} else { // still equal try at random for now...
-				if rand.Intn(2) == 0 {
-					return &g.Teams[argmax1], argmax1
-				}
-				return &g.Teams[argmax2], argmax2
-			}
-		}
-	} else {
-		return &g.Teams[argmax1], argmax1
-	}
-}
-
-// Get team with second highest rank in group based on points, goals for and against.
-func getSecondTeamInGroup(c appengine.Context, g *Tgroup, indexOfFirst int) (*Tteam, int) {
-
-	points := make([]int64, len(g.Points))
-	copy(points, g.Points)
-
-	points[indexOfFirst] = -1
-
-	argmax1, max1 := helpers.ArgMaxInt64(points)
-	points[argmax1] = -1
-	argmax2, max2 := helpers.ArgMaxInt64(points)
-	if max1 == max2 { // points equal try by difference
-		diff := make([]int64, len(points))
-		for i, _ := range points {
-			diff[i] = g.GoalsF[i] - g.GoalsA[i]
-		}
-		if diff[argmax1] > diff[argmax2] {
-			return &g.Teams[argmax1], argmax1
-		} else if diff[argmax1] < diff[argmax2] {
-			return &g.Teams[argmax2], argmax2
-		} else { // diff equal try by greatest number of goals scored
-			if g.GoalsF[argmax1] > g.GoalsF[argmax2] {
-				return &g.Teams[argmax1], argmax1
-			} else if g.GoalsF[argmax1] < g.GoalsF[argmax2] {
-				return &g.Teams[argmax2], argmax2
-			} else { // still equal try at random for now...
-				if rand.Intn(2) == 0 {
-					return &g.Teams[argmax1], argmax1
-				}
-				return &g.Teams[argmax2], argmax2
-			}
-		}
-	} else {
-		return &g.Teams[argmax1], argmax1
-	}
-}
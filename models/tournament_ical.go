@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"appengine"
+
+	"github.com/santiaago/gonawin/helpers/ical"
+)
+
+// Duration of a match event in the calendar feeds.
+const matchDuration = 2 * time.Hour
+
+// Get the matches of the tournament as calendar events, with a reminder when their predictions are locked.
+func (t *Tournament) CalendarEvents(c appengine.Context) []ical.Event {
+	mapIdTeams := GetTournamentBuilder(t).MapOfIdTeams(c, t)
+	matches := GetAllMatchesFromTournament(c, t)
+
+	events := make([]ical.Event, len(matches))
+	for i, m := range matches {
+		team1, team2 := mapIdTeams[m.TeamId1], mapIdTeams[m.TeamId2]
+		// teams of the second stage are not known yet, use the rule of the match instead.
+		if rule := strings.Split(m.Rule, " "); len(rule) == 2 {
+			team1, team2 = rule[0], rule[1]
+		}
+		summary := fmt.Sprintf("%s - %s", team1, team2)
+		if m.Finished {
+			summary = fmt.Sprintf("%s %d - %d %s", team1, m.Result1, m.Result2, team2)
+		}
+
+		events[i] = ical.Event{
+			UID:         fmt.Sprintf("%d-%d@gonawin", t.Id, m.Id),
+			Summary:     summary,
+			Description: t.Name,
+			Location:    m.Location,
+			Start:       m.Date,
+			End:         m.Date.Add(matchDuration),
+			Alarm:       m.LockDate(),
+			AlarmText:   fmt.Sprintf("Last chance to predict %s - %s", team1, team2),
+		}
+	}
+	return events
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"time"
+
+	"appengine"
+	"appengine/datastore"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// Deadline policies of a tournament, they define when the predictions of a match are locked.
+const (
+	LockPolicyMatch      = "match"      // each match is locked at its own kickoff.
+	LockPolicyMatchday   = "matchday"   // all matches of a day are locked at the first kickoff of the day.
+	LockPolicyPhase      = "phase"      // all matches of a phase are locked at the first kickoff of the phase.
+	LockPolicyTournament = "tournament" // all matches are locked at the opening match.
+)
+
+// Check if a policy is a valid deadline policy.
+func IsLockPolicyValid(policy string) bool {
+	switch policy {
+	case LockPolicyMatch, LockPolicyMatchday, LockPolicyPhase, LockPolicyTournament:
+		return true
+	}
+	return false
+}
+
+// Date the predictions of the match are locked.
+// The kickoff of the match is used if no lock date was set by the deadline policy of the tournament.
+func (m *Tmatch) LockDate() time.Time {
+	if m.LockAt.IsZero() {
+		return m.Date
+	}
+	return m.LockAt
+}
+
+// Compute the lock date of each match given a deadline policy, an offset before kickoff and the phase intervals of the tournament.
+// Returns a map of lock dates by match id.
+func lockDates(policy string, offset time.Duration, matches []*Tmatch, limits map[string][]int64) map[int64]time.Time {
+	// key of the group of matches sharing the same deadline.
+	groupOf := func(m *Tmatch) string {
+		switch policy {
+		case LockPolicyMatchday:
+			return m.Date.Format("2006-01-02")
+		case LockPolicyPhase:
+			for name, limit := range limits {
+				if m.IdNumber >= limit[0] && m.IdNumber <= limit[1] {
+					return name
+				}
+			}
+		case LockPolicyTournament:
+			return ""
+		}
+		return m.Date.String()
+	}
+
+	firstKickoff := make(map[string]time.Time)
+	for _, m := range matches {
+		g := groupOf(m)
+		if first, ok := firstKickoff[g]; !ok || m.Date.Before(first) {
+			firstKickoff[g] = m.Date
+		}
+	}
+
+	dates := make(map[int64]time.Time)
+	for _, m := range matches {
+		if policy == LockPolicyMatch {
+			dates[m.Id] = m.Date.Add(-offset)
+		} else {
+			dates[m.Id] = firstKickoff[groupOf(m)].Add(-offset)
+		}
+	}
+	return dates
+}
+
+// Set the deadline policy of the tournament and update the lock date of all its matches.
+// The offset is the number of minutes before kickoff the predictions are locked.
+func (t *Tournament) SetLockPolicy(c appengine.Context, policy string, offset int64) error {
+	t.LockPolicy = policy
+	t.LockOffset = offset
+	if err := t.Update(c); err != nil {
+		return err
+	}
+	return t.UpdateLockDates(c)
+}
+
+// Update the lock date of the matches of the tournament following its deadline policy.
+func (t *Tournament) UpdateLockDates(c appengine.Context) error {
+	policy := t.LockPolicy
+	if !IsLockPolicyValid(policy) {
+		policy = LockPolicyMatch
+	}
+	matches := GetAllMatchesFromTournament(c, t)
+	limits := GetTournamentBuilder(t).MapOfPhaseIntervals()
+
+	dates := lockDates(policy, time.Duration(t.LockOffset)*time.Minute, matches, limits)
+	for _, m := range matches {
+		m.LockAt = dates[m.Id]
+	}
+	return UpdateMatches(c, matches)
+}
+
+// Lock the predictions of a match.
+// The automatic predicts of the users who missed the deadline are queued, the crowd consensus of the match is computed after them.
+func (t *Tournament) LockMatch(c appengine.Context, m *Tmatch) error {
+	m.CanPredict = false
+	if err := UpdateMatch(c, m); err != nil {
+		return err
+	}
+	// the consensus is computed by the auto predict task once the automatic predicts exist.
+	if err := t.QueueAutoPredict(c, m); err != nil {
+		log.Errorf(c, "Tournament.LockMatch: unable to queue auto predict of match %v: %v", m.Id, err)
+	}
+	return nil
+}
+
+// Get the matches whose deadline has passed but that still accept predictions.
+func dueMatches(matches []*Tmatch, now time.Time) []*Tmatch {
+	var due []*Tmatch
+	for _, m := range matches {
+		if m.Ready && m.CanPredict && !m.Finished && !now.Before(m.LockDate()) {
+			due = append(due, m)
+		}
+	}
+	return due
+}
+
+// Lock the matches of the running tournaments whose deadline has passed.
+// Returns the number of locked matches.
+func LockDueMatches(c appengine.Context, now time.Time) int {
+	var tournaments []*Tournament
+	if _, err := datastore.NewQuery("Tournament").GetAll(c, &tournaments); err != nil {
+		log.Errorf(c, "LockDueMatches: error occurred during GetAll: %v", err)
+		return 0
+	}
+
+	count := 0
+	for _, t := range tournaments {
+		// sandboxes replay past matches and archived tournaments are read-only.
+		if t.Archived || t.IsSandbox() {
+			continue
+		}
+		for _, m := range dueMatches(GetAllMatchesFromTournament(c, t), now) {
+			if err := t.LockMatch(c, m); err != nil {
+				log.Errorf(c, "LockDueMatches: unable to lock match %v of tournament %v: %v", m.Id, t.Id, err)
+				continue
+			}
+			count++
+		}
+	}
+	return count
+}
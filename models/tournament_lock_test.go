@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockDates(t *testing.T) {
+	day1 := time.Date(2014, time.June, 12, 17, 0, 0, 0, time.UTC)
+	day2 := time.Date(2014, time.June, 13, 13, 0, 0, 0, time.UTC)
+	matches := []*Tmatch{
+		{Id: 1, IdNumber: 1, Date: day1},
+		{Id: 2, IdNumber: 2, Date: day2.Add(3 * time.Hour)},
+		{Id: 3, IdNumber: 3, Date: day2},
+		{Id: 4, IdNumber: 4, Date: day2.Add(24 * time.Hour)},
+	}
+	limits := map[string][]int64{"First Stage": {1, 3}, "Finals": {4, 4}}
+
+	tests := []struct {
+		name   string
+		policy string
+		offset time.Duration
+		want   map[int64]time.Time
+	}{
+		{
+			name:   "Match",
+			policy: LockPolicyMatch,
+			offset: time.Hour,
+			want:   map[int64]time.Time{1: day1.Add(-time.Hour), 2: day2.Add(2 * time.Hour), 3: day2.Add(-time.Hour), 4: day2.Add(23 * time.Hour)},
+		},
+		{
+			name:   "Matchday",
+			policy: LockPolicyMatchday,
+			want:   map[int64]time.Time{1: day1, 2: day2, 3: day2, 4: day2.Add(24 * time.Hour)},
+		},
+		{
+			name:   "Phase",
+			policy: LockPolicyPhase,
+			want:   map[int64]time.Time{1: day1, 2: day1, 3: day1, 4: day2.Add(24 * time.Hour)},
+		},
+		{
+			name:   "Tournament",
+			policy: LockPolicyTournament,
+			offset: time.Minute,
+			want:   map[int64]time.Time{1: day1.Add(-time.Minute), 2: day1.Add(-time.Minute), 3: day1.Add(-time.Minute), 4: day1.Add(-time.Minute)},
+		},
+	}
+	for _, test := range tests {
+		got := lockDates(test.policy, test.offset, matches, limits)
+		for id, want := range test.want {
+			if !got[id].Equal(want) {
+				t.Errorf("lockDates(%q): got %v for match %d wanted %v", test.name, got[id], id, want)
+			}
+		}
+	}
+}
+
+func TestDueMatches(t *testing.T) {
+	now := time.Date(2014, time.June, 12, 17, 0, 0, 0, time.UTC)
+	matches := []*Tmatch{
+		{Id: 1, Date: now.Add(-time.Hour), Ready: true, CanPredict: true},
+		{Id: 2, Date: now.Add(time.Hour), Ready: true, CanPredict: true},
+		{Id: 3, Date: now.Add(time.Hour), LockAt: now, Ready: true, CanPredict: true},
+		{Id: 4, Date: now.Add(-time.Hour), Ready: true, CanPredict: false},
+		{Id: 5, Date: now.Add(-time.Hour), Ready: true, CanPredict: true, Finished: true},
+		{Id: 6, Date: now.Add(-time.Hour), CanPredict: true},
+	}
+
+	due := dueMatches(matches, now)
+	if len(due) != 2 || due[0].Id != 1 || due[1].Id != 3 {
+		t.Errorf("dueMatches: got %v wanted matches 1 and 3", due)
+	}
+}
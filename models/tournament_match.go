@@ -29,18 +29,21 @@ import (
 )
 
 type Tmatch struct {
-	Id         int64     // datastore match id
-	IdNumber   int64     // id of match in tournament
-	Date       time.Time // date of match
-	TeamId1    int64     // id of 1st team
-	TeamId2    int64     // id of 2nd team
-	Location   string    // match location
-	Rule       string    // we use this field to store a specific match rule.
-	Result1    int64     // result of 1st team
-	Result2    int64     // result of 2nd team
-	Finished   bool      // is match finished
-	Ready      bool      // is match ready for predictions.
-	CanPredict bool      // can user make a prediction (used to block predictions when match has started).
+	Id          int64     // datastore match id
+	IdNumber    int64     // id of match in tournament
+	Date        time.Time // date of match
+	TeamId1     int64     // id of 1st team
+	TeamId2     int64     // id of 2nd team
+	Location    string    // match location
+	Rule        string    // we use this field to store a specific match rule.
+	Result1     int64     // result of 1st team
+	Result2     int64     // result of 2nd team
+	Finished    bool      // is match finished
+	Ready       bool      // is match ready for predictions.
+	CanPredict  bool      // can user make a prediction (used to block predictions when match has started).
+	LockAt      time.Time // date predictions are locked following the deadline policy of the tournament, kickoff if zero.
+	FirstScorer int64     // team that scored first: 1 or 2, 0 if no goal or unknown.
+	VenueId     int64     // id of the venue of the match, 0 if unknown.
 }
 
 // Get a Tmatch entity by id.
@@ -55,6 +58,11 @@ func MatchById(c appengine.Context, matchId int64) (*Tmatch, error) {
 	return &m, nil
 }
 
+// Is match locked: predictions are blocked or the deadline of the match has passed.
+func (m *Tmatch) IsLocked() bool {
+	return !m.CanPredict || m.Finished || time.Now().After(m.LockDate())
+}
+
 // From an array of ids return the corresponding array of matches.
 func Matches(c appengine.Context, matchIds []int64) []*Tmatch {
 	var matches []*Tmatch
@@ -129,6 +137,7 @@ func DestroyMatches(c appengine.Context, matchIds []int64) error {
 }
 
 // Set results in an array of matches and triggers a match update and group update.
+// It is used to simulate matches: no activity is published and the tournament is not archived once finished.
 func SetResults(c appengine.Context, matches []*Tmatch, results1 []int64, results2 []int64, t *Tournament) error {
 	desc := "Set Results:"
 	if len(matches) != len(results1) || len(matches) != len(results2) {
@@ -159,21 +168,20 @@ func SetResults(c appengine.Context, matches []*Tmatch, results1 []int64, result
 		log.Infof(c, "%s Trigger current match: %v", desc, m.Id)
 
 		if ismatch, g := t.IsMatchInGroup(c, m); ismatch == true {
-			if err := UpdatePointsAndGoals(c, g, m, t); err != nil {
-				log.Errorf(c, "%s Update Points and Goals: unable to update points and goals for group for match with id:%v error: %v", desc, m.IdNumber, err)
-				return errors.New(helpers.ErrorCodeMatchCannotUpdate)
-			}
-			if err := UpdateGroup(c, g); err != nil {
-				log.Errorf(c, "%s unable to update group: %v", desc, err)
+			if err := g.UpdateStandings(c); err != nil {
+				log.Errorf(c, "%s unable to update standings of group for match with id:%v error: %v", desc, m.IdNumber, err)
 				return err
 			}
 		}
-		if isLast, phaseId := lastMatchOfPhase(c, m, &phases); isLast == true {
+		if completed, phaseId := phaseCompleted(m, phases); completed == true {
 			log.Infof(c, "%s -------------------------------------------------->", desc)
 			log.Infof(c, "%s Trigger update of next phase here: next phase: %v", desc, phaseId+1)
 			log.Infof(c, "%s Trigger update of next phase here: next phase: %v", desc, m)
+			// no activity is published on a simulation.
 			if int(phaseId+1) < len(phases) {
-				UpdateNextPhase(c, t, &phases[phaseId], &phases[phaseId+1])
+				if _, err := UpdateNextPhase(c, t, &phases[phaseId], &phases[phaseId+1]); err != nil {
+					log.Errorf(c, "%s unable to update next phase: %v", desc, err)
+				}
 			}
 			log.Infof(c, "%s -------------------------------------------------->", desc)
 			// update flag first phase complete.
@@ -185,7 +193,6 @@ func SetResults(c appengine.Context, matches []*Tmatch, results1 []int64, result
 	}
 
 	log.Infof(c, "%s points and goals updated", desc)
-
 	return nil
 }
 
@@ -211,24 +218,29 @@ func SetResult(c appengine.Context, m *Tmatch, result1 int64, result2 int64, t *
 		if err1 := t.UpdateTeamsAccuracy(c, m); err1 != nil {
 			log.Errorf(c, "%s unable to update teams score on match with id: %v, %v", desc, m.Id, err)
 		}
+		// settle wagers of the match.
+		if t.Wagering {
+			if err1 := t.QueueSettleWagers(c, m); err1 != nil {
+				log.Errorf(c, "%s unable to settle wagers on match with id: %v, %v", desc, m.Id, err1)
+			}
+		}
 	}
 	if ismatch, g := t.IsMatchInGroup(c, m); ismatch == true {
-		if err := UpdatePointsAndGoals(c, g, m, t); err != nil {
-			log.Errorf(c, "%s Update Points and Goals: unable to update points and goals for group for match with id:%v error: %v", desc, m.IdNumber, err)
+		if err := g.UpdateStandings(c); err != nil {
+			log.Errorf(c, "%s unable to update standings of group for match with id:%v error: %v", desc, m.IdNumber, err)
 			return errors.New(helpers.ErrorCodeMatchCannotUpdate)
 		}
-		UpdateGroup(c, g)
 	}
 
 	if t.TwoLegged == false {
 		allMatches := GetAllMatchesFromTournament(c, t)
 		phases := MatchesGroupByPhase(t, allMatches)
-		if isLast, phaseId := lastMatchOfPhase(c, m, &phases); isLast == true {
+		if completed, phaseId := phaseCompleted(m, phases); completed == true {
 			log.Infof(c, "%s -------------------------------------------------->", desc)
 			log.Infof(c, "%s Trigger update of next phase here: next phase: %v", desc, phaseId+1)
 			log.Infof(c, "%s Trigger update of next phase here: next phase: %v", desc, m)
-			if int(phaseId+1) < len(phases) {
-				UpdateNextPhase(c, t, &phases[phaseId], &phases[phaseId+1])
+			if err := t.AdvancePhase(c, phases, phaseId); err != nil {
+				log.Errorf(c, "%s unable to update next phase: %v", desc, err)
 			}
 			log.Infof(c, "%s -------------------------------------------------->", desc)
 			// update flag first phase complete.
@@ -239,6 +251,7 @@ func SetResult(c appengine.Context, m *Tmatch, result1 int64, result2 int64, t *
 		}
 	}
 
+	t.archiveWhenFinished(c)
 	return nil
 }
 
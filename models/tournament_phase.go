@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"appengine"
+	"appengine/datastore"
 
 	"github.com/santiaago/gonawin/helpers/log"
 )
@@ -45,26 +46,50 @@ func (a ByDate) Len() int           { return len(a) }
 func (a ByDate) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ByDate) Less(i, j int) bool { return a[i].Date.Before(a[j].Date) }
 
-// Check if the match m passed as argument is the last match of a phase in a specific tournament.
+// Check if the phase of the match m passed as argument is complete, that is all its matches are finished.
 // it returns a boolean and the index of the phase the match was found
-func lastMatchOfPhase(c appengine.Context, m *Tmatch, phases *[]Tphase) (bool, int64) {
-
-	for i, ph := range *phases {
-		if n := len(ph.Days); n >= 1 {
-			lastDay := ph.Days[n-1]
-			if n = len(lastDay.Matches); n >= 1 {
-				lastMatch := lastDay.Matches[n-1]
-				if lastMatch.IdNumber == m.IdNumber {
-					return true, int64(i)
+func phaseCompleted(m *Tmatch, phases []Tphase) (bool, int64) {
+	for i, ph := range phases {
+		found, finished := false, true
+		for _, d := range ph.Days {
+			for _, pm := range d.Matches {
+				if pm.IdNumber == m.IdNumber {
+					found = true
+				}
+				if !pm.Finished {
+					finished = false
 				}
 			}
 		}
+		if found {
+			return finished, int64(i)
+		}
 	}
 	return false, int64(-1)
 }
 
+// Set the teams of the phase following a completed phase and notify the participants that its matches are open for prediction.
+// Only the matches not set yet are updated, so calling it more than once for the same phase has no effect.
+func (t *Tournament) AdvancePhase(c appengine.Context, phases []Tphase, phaseId int64) error {
+	if phaseId < 0 || int(phaseId+1) >= len(phases) {
+		return nil
+	}
+	opened, err := UpdateNextPhase(c, t, &phases[phaseId], &phases[phaseId+1])
+	if err != nil {
+		return err
+	}
+	for _, name := range opened {
+		log.Infof(c, "Advance phase: %v is set in tournament %v", name, t.Id)
+		if err := t.Publish(c, "phase", "opened predictions for the", ActivityEntity{Type: "phase", DisplayName: name}, ActivityEntity{}); err != nil {
+			log.Errorf(c, "Advance phase: unable to publish activity: %v", err)
+		}
+	}
+	return nil
+}
+
 // Update next phase in tournament.
-func UpdateNextPhase(c appengine.Context, t *Tournament, currentphase *Tphase, nextphase *Tphase) error {
+// The matches of the next phase are updated in a transaction, it returns the names of the phases whose matches were set.
+func UpdateNextPhase(c appengine.Context, t *Tournament, currentphase *Tphase, nextphase *Tphase) ([]string, error) {
 
 	// the array of phases that will be update.
 	// it is an array as a phase can trigger an update in multiple phases, like semi-finals
@@ -79,17 +104,20 @@ func UpdateNextPhase(c appengine.Context, t *Tournament, currentphase *Tphase, n
 		// compute ranking of groups
 		// get all groups.
 		groups := Groups(c, t.GroupIds)
+		// the first two teams of the standings go through, the same ranking as the one displayed.
 		for _, g := range groups {
-			team1, argTeam1 := getFirstTeamInGroup(c, g)
-			team2, _ := getSecondTeamInGroup(c, g, argTeam1)
-			mapOfTeams["1"+g.Name] = team1
-			mapOfTeams["2"+g.Name] = team2
+			rows := g.Standings(c)
+			if len(rows) < groupQualifiers {
+				return nil, errors.New(fmt.Sprintf("Cannot rank group %s in tournament =%d", g.Name, t.Id))
+			}
+			mapOfTeams["1"+g.Name] = &rows[0].Team
+			mapOfTeams["2"+g.Name] = &rows[1].Team
 		}
 	} else {
 		// compute ranking just by match winners
 		if currentphase.Name == cFinals || currentphase.Name == cThirdPlace {
 			// nothing to do.
-			return nil
+			return nil, nil
 		}
 
 		currentmatches := GetMatchesByPhase(c, t, currentphase.Name)
@@ -139,37 +167,79 @@ func UpdateNextPhase(c appengine.Context, t *Tournament, currentphase *Tphase, n
 		}
 	}
 
-	// update phase (matches) with new teams
+	// teams of the matches of the phases to update, by match id.
+	type matchTeams struct {
+		phase   string
+		teamId1 int64
+		teamId2 int64
+	}
+	var ids []int64
+	teams := make(map[int64]matchTeams)
 	for _, ph := range phases {
 		matches := GetMatchesByPhase(c, t, ph.Name)
-		for i, m := range matches {
+		for _, m := range matches {
 			log.Infof(c, "Update Next phase: current rule: %v", m.Rule)
 			rule := strings.Split(m.Rule, " ")
 			if len(rule) != 2 {
 				continue
 			}
 
-			if val, ok := mapOfTeams[rule[0]]; ok {
-				log.Infof(c, "Update Next phase: match found: %v", val.Name)
-				matches[i].TeamId1 = val.Id
-			} else {
-				return errors.New(fmt.Sprintf("Cannot parse rule in tournament =%d", t.Id))
-			}
-			if val, ok := mapOfTeams[rule[1]]; ok {
-				log.Infof(c, "Update Next phase: match found: %v", val.Name)
-				matches[i].TeamId2 = val.Id
-			} else {
-				return errors.New(fmt.Sprintf("Cannot parse rule in tournament =%d", t.Id))
+			team1, ok1 := mapOfTeams[rule[0]]
+			team2, ok2 := mapOfTeams[rule[1]]
+			if !ok1 || !ok2 {
+				return nil, errors.New(fmt.Sprintf("Cannot parse rule in tournament =%d", t.Id))
 			}
-			matches[i].Rule = ""
-			matches[i].Ready = true
-			matches[i].CanPredict = true
+			log.Infof(c, "Update Next phase: match found: %v - %v", team1.Name, team2.Name)
+			ids = append(ids, m.Id)
+			teams[m.Id] = matchTeams{ph.Name, team1.Id, team2.Id}
 		}
-		if err := UpdateMatches(c, matches); err != nil {
-			log.Errorf(c, "Set Results: unable to set results on matches: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// update phase (matches) with new teams
+	var opened []string
+	err := datastore.RunInTransaction(c, func(c appengine.Context) error {
+		opened = nil
+		keys := make([]*datastore.Key, len(ids))
+		matches := make([]*Tmatch, len(ids))
+		for i, id := range ids {
+			keys[i] = MatchKeyById(c, id)
+			matches[i] = new(Tmatch)
+		}
+		if err := datastore.GetMulti(c, keys, matches); err != nil {
 			return err
 		}
-	}
 
-	return nil
+		var updatedKeys []*datastore.Key
+		var updatedMatches []*Tmatch
+		for i, m := range matches {
+			// the match was already set by a previous update.
+			if len(strings.Split(m.Rule, " ")) != 2 {
+				continue
+			}
+			mt := teams[ids[i]]
+			m.TeamId1 = mt.teamId1
+			m.TeamId2 = mt.teamId2
+			m.Rule = ""
+			m.Ready = true
+			m.CanPredict = true
+			updatedKeys = append(updatedKeys, keys[i])
+			updatedMatches = append(updatedMatches, m)
+			if len(opened) == 0 || opened[len(opened)-1] != mt.phase {
+				opened = append(opened, mt.phase)
+			}
+		}
+		if len(updatedKeys) == 0 {
+			return nil
+		}
+		_, err := datastore.PutMulti(c, updatedKeys, updatedMatches)
+		return err
+	}, &datastore.TransactionOptions{XG: true})
+	if err != nil {
+		log.Errorf(c, "Update Next phase: unable to update matches: %v", err)
+		return nil, err
+	}
+	return opened, nil
 }
@@ -0,0 +1,50 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+)
+
+func TestPhaseCompleted(t *testing.T) {
+	phases := []Tphase{
+		{Name: cRoundOf16, Days: []Tday{
+			{Matches: []Tmatch{{IdNumber: 1, Finished: true}, {IdNumber: 2, Finished: true}}},
+			{Matches: []Tmatch{{IdNumber: 3, Finished: true}}},
+		}},
+		{Name: cQuarterFinals, Days: []Tday{
+			{Matches: []Tmatch{{IdNumber: 4, Finished: true}}},
+			{Matches: []Tmatch{{IdNumber: 5}}},
+		}},
+	}
+
+	tests := []struct {
+		name      string
+		match     Tmatch
+		completed bool
+		phaseId   int64
+	}{
+		{"All matches finished", Tmatch{IdNumber: 2}, true, 0},
+		{"Last match not finished", Tmatch{IdNumber: 4}, false, 1},
+		{"Unknown match", Tmatch{IdNumber: 6}, false, -1},
+	}
+	for _, test := range tests {
+		if completed, phaseId := phaseCompleted(&test.match, phases); completed != test.completed || phaseId != test.phaseId {
+			t.Errorf("phaseCompleted(%q): got %v, %d wanted %v, %d", test.name, completed, phaseId, test.completed, test.phaseId)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"appengine"
+	"appengine/datastore"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// TournamentProgress holds the progression of a tournament with respect to its finished matches.
+type TournamentProgress struct {
+	Finished     int64   // number of finished matches.
+	Total        int64   // number of matches.
+	Remaining    int64   // number of matches left to play.
+	Ratio        float64 // finished matches over all matches, between 0 and 1.
+	CurrentPhase string  // first phase with matches left to play, empty when all matches are finished.
+	NextMatch    *Tmatch // earliest match left to play, nil when all matches are finished.
+	Phases       []PhaseProgress
+}
+
+// PhaseProgress holds the progression of a phase of a tournament.
+type PhaseProgress struct {
+	Name     string
+	Finished int64
+	Total    int64
+	Ratio    float64
+}
+
+// The progression is a number between 0 and 1 with the ratio of finished matches of the tournament.
+func (t *Tournament) Progress(c appengine.Context) float64 {
+	return t.ProgressDetails(c).Ratio
+}
+
+// Get the progression of the tournament, overall and for each phase.
+func (t *Tournament) ProgressDetails(c appengine.Context) *TournamentProgress {
+	tb := GetTournamentBuilder(t)
+	return progressOf(tb.ArrayOfPhases(), tb.MapOfPhaseIntervals(), t.matchesMulti(c))
+}
+
+// Get all the matches of a tournament in a single datastore call, matches not found are skipped.
+func (t *Tournament) matchesMulti(c appengine.Context) []*Tmatch {
+	ids := append(append([]int64{}, t.Matches1stStage...), t.Matches2ndStage...)
+	keys := make([]*datastore.Key, len(ids))
+	matches := make([]*Tmatch, len(ids))
+	for i, id := range ids {
+		keys[i] = MatchKeyById(c, id)
+		matches[i] = new(Tmatch)
+	}
+	err := datastore.GetMulti(c, keys, matches)
+	if err == nil {
+		return matches
+	}
+	merr, ok := err.(appengine.MultiError)
+	if !ok {
+		log.Errorf(c, "Tournament matches: unable to get matches of tournament %d: %v", t.Id, err)
+		return nil
+	}
+	var found []*Tmatch
+	for i, e := range merr {
+		if e == nil {
+			found = append(found, matches[i])
+		}
+	}
+	return found
+}
+
+// Compute the progression of a tournament from its matches, the phases are given by their names and
+// their intervals of match id numbers. The next match is the earliest unfinished match, the first by id number on a tie.
+func progressOf(phaseNames []string, limits map[string][]int64, matches []*Tmatch) *TournamentProgress {
+	p := &TournamentProgress{Phases: make([]PhaseProgress, len(phaseNames))}
+	for i, name := range phaseNames {
+		p.Phases[i].Name = name
+	}
+
+	for _, m := range matches {
+		p.Total++
+		if m.Finished {
+			p.Finished++
+		} else if p.NextMatch == nil || m.Date.Before(p.NextMatch.Date) || (m.Date.Equal(p.NextMatch.Date) && m.IdNumber < p.NextMatch.IdNumber) {
+			p.NextMatch = m
+		}
+		for i, name := range phaseNames {
+			limit := limits[name]
+			if len(limit) != 2 || m.IdNumber < limit[0] || m.IdNumber > limit[1] {
+				continue
+			}
+			p.Phases[i].Total++
+			if m.Finished {
+				p.Phases[i].Finished++
+			}
+		}
+	}
+
+	p.Remaining = p.Total - p.Finished
+	p.Ratio = ratio(p.Finished, p.Total)
+	for i, ph := range p.Phases {
+		p.Phases[i].Ratio = ratio(ph.Finished, ph.Total)
+		if len(p.CurrentPhase) == 0 && ph.Finished < ph.Total {
+			p.CurrentPhase = ph.Name
+		}
+	}
+	return p
+}
+
+// Ratio of two counts, 0 when there is nothing to count.
+func ratio(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressOf(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2014, time.June, d, 17, 0, 0, 0, time.UTC) }
+	phases := []string{cFirstStage, cFinals}
+	limits := map[string][]int64{cFirstStage: {1, 3}, cFinals: {4, 4}}
+	matches := []*Tmatch{
+		{IdNumber: 1, Date: day(12), Finished: true},
+		{IdNumber: 2, Date: day(20)}, // postponed match.
+		{IdNumber: 3, Date: day(13), Finished: true},
+		{IdNumber: 4, Date: day(14)},
+	}
+
+	p := progressOf(phases, limits, matches)
+	if p.Finished != 2 || p.Total != 4 || p.Remaining != 2 || p.Ratio != 0.5 {
+		t.Errorf("progress: got %+v", p)
+	}
+	if p.CurrentPhase != cFirstStage {
+		t.Errorf("current phase: got %q wanted %q", p.CurrentPhase, cFirstStage)
+	}
+	if p.NextMatch == nil || p.NextMatch.IdNumber != 4 {
+		t.Errorf("next match: got %+v wanted match 4", p.NextMatch)
+	}
+	if p.Phases[0].Finished != 2 || p.Phases[0].Total != 3 || p.Phases[1].Finished != 0 || p.Phases[1].Total != 1 {
+		t.Errorf("phases: got %+v", p.Phases)
+	}
+
+	if empty := progressOf(phases, limits, nil); empty.Ratio != 0 || empty.NextMatch != nil || len(empty.CurrentPhase) != 0 {
+		t.Errorf("progress without matches: got %+v", empty)
+	}
+}
@@ -64,19 +64,38 @@ func (t *Tournament) UpdateTeamsAccuracy(c appengine.Context, m *Tmatch) error {
 	teams := t.Teams(c)
 
 	teamsToUpdate := make([]*Team, 0)
+	var bonuses map[int64]int64 // rarity bonuses, computed once for the teams that enabled them.
 	for _, team := range teams {
 		sumScore := int64(0)
+		scores := make(map[int64]int64)
+		teamBonuses := make(map[int64]int64)
 		players := team.Players(c)
 		if len(players) == 0 {
 			// a team with 0 players? this should never happen, just skip to the next.
 			continue
 		}
 		max := 3 * len(players) // maximum score for team in current match.
+		// the rarity bonus of the members is added to the accuracy of the teams that enabled it.
+		rarity := team.HasRarityBonus(t.Id)
+		if rarity && bonuses == nil {
+			bonuses = t.RarityBonuses(c, m)
+		}
 		for _, u := range players {
 			if score, err := u.ScoreForMatch(c, m); err != nil {
 				log.Errorf(c, "%s unable udpate user %v score: %v", desc, u.Id, err)
 			} else {
+				if score > 0 && team.AutoPredictCut > 0 {
+					// automatic predicts are scored at reduced value if the team chose so, rounded to the nearest point.
+					if p, _ := u.PredictFromMatchId(c, m.Id); p != nil && p.Automatic {
+						score = (score*(100-team.AutoPredictCut) + 50) / 100
+					}
+				}
+				if rarity {
+					teamBonuses[u.Id] = bonuses[u.Id]
+					sumScore += bonuses[u.Id]
+				}
 				sumScore += score
+				scores[u.Id] = score
 			}
 		}
 
@@ -99,14 +118,14 @@ func (t *Tournament) UpdateTeamsAccuracy(c appengine.Context, m *Tmatch) error {
 				team.AddTournamentAcc(c, acc1.Id, t.Id)
 				log.Infof(c, "%s accuracy exists now, lets update it", desc)
 				var err error
-				if computedAcc, err = acc1.Add(c, newAcc); err != nil {
+				if computedAcc, err = acc1.Add(c, newAcc, m, scores, teamBonuses); err != nil {
 					log.Errorf(c, "%s unable to add accuracy of team %v, ", desc, team.Id, err)
 				}
 			}
 		} else {
 			log.Infof(c, "%s accuracy entity exists, lets update it", desc)
 			var err error
-			if computedAcc, err = acc.Add(c, newAcc); err != nil {
+			if computedAcc, err = acc.Add(c, newAcc, m, scores, teamBonuses); err != nil {
 				log.Errorf(c, "%s unable to add accuracy of team %v, ", desc, team.Id, err)
 			}
 		}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"appengine"
+	"appengine/datastore"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// Average number of goals scored by a team in a match between two teams of the same strength.
+const simulationGoals = 1.35
+
+// Simulate the score of a match between two teams with a Poisson goals model.
+// The expected goals of a team grow exponentially with the difference of strength ratings of the teams.
+// In a knockout match a draw goes to extra time, then the winner of the penalty shootout gets one more goal,
+// so a knockout match is never drawn.
+func simulateScore(r *rand.Rand, strength1, strength2 float64, knockout bool) (int64, int64) {
+	lambda1 := simulationGoals * math.Exp(strength1-strength2)
+	lambda2 := simulationGoals * math.Exp(strength2-strength1)
+
+	result1, result2 := poisson(r, lambda1), poisson(r, lambda2)
+	if !knockout || result1 != result2 {
+		return result1, result2
+	}
+	// extra time lasts a third of a match.
+	result1 += poisson(r, lambda1/3)
+	result2 += poisson(r, lambda2/3)
+	if result1 != result2 {
+		return result1, result2
+	}
+	// penalty shootout.
+	if r.Intn(2) == 0 {
+		return result1 + 1, result2
+	}
+	return result1, result2 + 1
+}
+
+// Draw a number from a Poisson distribution of mean lambda (Knuth's algorithm).
+func poisson(r *rand.Rand, lambda float64) int64 {
+	l := math.Exp(-lambda)
+	k := int64(0)
+	for p := r.Float64(); p > l; p *= r.Float64() {
+		k++
+	}
+	return k
+}
+
+// Check if the tournament can be simulated. Simulated results would change the scores of real users,
+// so only a tournament without participants nor predicts can be simulated.
+func (t *Tournament) CanSimulate(c appengine.Context) bool {
+	if len(t.UserIds) > 0 {
+		return false
+	}
+	for _, id := range append(append([]int64{}, t.Matches1stStage...), t.Matches2ndStage...) {
+		q := datastore.NewQuery("Predict").Filter("MatchId"+" =", id).KeysOnly().Limit(1)
+		if keys, err := q.GetAll(c, nil); err != nil || len(keys) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Simulate the matches of a phase of the tournament and set their results.
+// The score of a match only depends on the seed and the match, so a simulation can be replayed with the same seed.
+// Matches whose teams are not known yet are skipped, it returns the simulated matches.
+func (t *Tournament) SimulatePhase(c appengine.Context, phaseName string, seed int64) ([]*Tmatch, error) {
+	desc := "Simulate phase:"
+	var matches []*Tmatch
+	for _, m := range GetMatchesByPhase(c, t, phaseName) {
+		if len(strings.Split(m.Rule, " ")) == 2 {
+			log.Infof(c, "%s teams of match %d are not known yet: %v", desc, m.IdNumber, m.Rule)
+			continue
+		}
+		matches = append(matches, m)
+	}
+	if len(matches) == 0 {
+		return matches, nil
+	}
+
+	strengths := t.teamStrengths(c)
+	results1 := make([]int64, len(matches))
+	results2 := make([]int64, len(matches))
+	for i, m := range matches {
+		r := rand.New(rand.NewSource(seed + m.IdNumber))
+		results1[i], results2[i] = simulateScore(r, strengths[m.TeamId1], strengths[m.TeamId2], phaseName != cFirstStage)
+		log.Infof(c, "%s match %d: %d - %d | %d - %d", desc, m.IdNumber, m.TeamId1, m.TeamId2, results1[i], results2[i])
+	}
+	if err := SetResults(c, matches, results1, results2, t); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// Simulate the whole tournament, phase after phase.
+// Setting the results of a phase sets the teams of the next phases, so all matches are simulated with their real teams.
+// It returns the simulated matches.
+func (t *Tournament) Simulate(c appengine.Context, seed int64) ([]*Tmatch, error) {
+	var all []*Tmatch
+	for _, phaseName := range GetTournamentBuilder(t).ArrayOfPhases() {
+		matches, err := t.SimulatePhase(c, phaseName, seed)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range GetMatchesByPhase(c, t, phaseName) {
+			if !m.Finished {
+				return nil, errors.New(fmt.Sprintf("teams of match %d of phase %s were not set", m.IdNumber, phaseName))
+			}
+		}
+		all = append(all, matches...)
+	}
+	return all, nil
+}
+
+// Get the strength ratings of the teams of the tournament, by team id.
+func (t *Tournament) teamStrengths(c appengine.Context) map[int64]float64 {
+	strengths := make(map[int64]float64)
+	for _, g := range Groups(c, t.GroupIds) {
+		for _, team := range g.Teams {
+			strengths[team.Id] = team.Strength
+		}
+	}
+	for id := range MapOfIdTeams(c, t) {
+		if _, ok := strengths[id]; ok {
+			continue
+		}
+		if team, err := TTeamById(c, id); err == nil {
+			strengths[id] = team.Strength
+		}
+	}
+	return strengths
+}
+
+// Set the strength rating of a team of the tournament, in the team entity and in its group.
+func (t *Tournament) SetTeamStrength(c appengine.Context, teamId int64, strength float64) (*Tteam, error) {
+	if _, ok := MapOfIdTeams(c, t)[teamId]; !ok {
+		return nil, errors.New(fmt.Sprintf("team %d is not in tournament %d", teamId, t.Id))
+	}
+	team, err := TTeamById(c, teamId)
+	if err != nil {
+		return nil, err
+	}
+	team.Strength = strength
+	if _, err := datastore.Put(c, datastore.NewKey(c, "Tteam", "", teamId, nil), team); err != nil {
+		return nil, err
+	}
+	for _, g := range Groups(c, t.GroupIds) {
+		for i := range g.Teams {
+			if g.Teams[i].Id != teamId {
+				continue
+			}
+			g.Teams[i].Strength = strength
+			if err := UpdateGroup(c, g); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return team, nil
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSimulateScore(t *testing.T) {
+	for seed := int64(0); seed < 1000; seed++ {
+		r1, r2 := simulateScore(rand.New(rand.NewSource(seed)), 0.3, -0.2, true)
+		if r1 == r2 {
+			t.Errorf("seed %d: knockout match drawn %d - %d", seed, r1, r2)
+		}
+		s1, s2 := simulateScore(rand.New(rand.NewSource(seed)), 0.3, -0.2, true)
+		if s1 != r1 || s2 != r2 {
+			t.Errorf("seed %d: got %d - %d wanted %d - %d", seed, s1, s2, r1, r2)
+		}
+	}
+
+	// a stronger team scores more goals on average.
+	r := rand.New(rand.NewSource(42))
+	var goals1, goals2 int64
+	for i := 0; i < 1000; i++ {
+		r1, r2 := simulateScore(r, 0.5, 0, false)
+		goals1 += r1
+		goals2 += r2
+	}
+	if goals1 <= goals2 {
+		t.Errorf("goals: got %d for the stronger team and %d for the weaker one", goals1, goals2)
+	}
+}
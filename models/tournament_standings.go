@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"sort"
+
+	"appengine"
+)
+
+// Qualification status of a team in its group.
+const (
+	StandingQualified  = "qualified"  // the team finishes in the qualifying places whatever the remaining results.
+	StandingEliminated = "eliminated" // the team cannot finish in the qualifying places anymore.
+	StandingContention = "contention" // the team is still in contention.
+)
+
+// Number of teams of a group that go through to the next phase.
+const groupQualifiers = 2
+
+// Number of results in the form of a team.
+const formLength = 5
+
+// A Standing is the row of a team in the standings table of its group.
+type Standing struct {
+	Team     Tteam
+	Played   int64
+	Won      int64
+	Drawn    int64
+	Lost     int64
+	GoalsF   int64
+	GoalsA   int64
+	GoalDiff int64
+	Points   int64
+	Form     []string // last results of the team, the most recent first: W, D or L.
+	Status   string   // qualified, eliminated or contention.
+}
+
+// Get the standings of a group computed from the results of its matches, the first team first.
+func (g *Tgroup) Standings(c appengine.Context) []*Standing {
+	ids := make([]int64, len(g.Matches))
+	for i, m := range g.Matches {
+		ids[i] = m.Id
+	}
+	return standings(g.Teams, Matches(c, ids))
+}
+
+// Update the points and goals of the teams of a group from the results of its matches.
+func (g *Tgroup) UpdateStandings(c appengine.Context) error {
+	rows := g.Standings(c)
+	for i, t := range g.Teams {
+		for _, s := range rows {
+			if s.Team.Id == t.Id {
+				g.Points[i] = s.Points
+				g.GoalsF[i] = s.GoalsF
+				g.GoalsA[i] = s.GoalsA
+			}
+		}
+	}
+	return UpdateGroup(c, g)
+}
+
+// Compute the standings of teams given the matches they play.
+func standings(teams []Tteam, matches []*Tmatch) []*Standing {
+	rows := make(map[int64]*Standing)
+	remaining := make(map[int64]int64)
+	table := make([]*Standing, len(teams))
+	for i, t := range teams {
+		table[i] = &Standing{Team: t, Form: []string{}}
+		rows[t.Id] = table[i]
+	}
+
+	played := make([]*Tmatch, 0)
+	for _, m := range matches {
+		if m.Finished {
+			played = append(played, m)
+		} else {
+			remaining[m.TeamId1]++
+			remaining[m.TeamId2]++
+		}
+	}
+	// most recent match first for the form of the teams.
+	sort.Sort(sort.Reverse(MatchByDate(played)))
+
+	for _, m := range played {
+		s1, ok1 := rows[m.TeamId1]
+		s2, ok2 := rows[m.TeamId2]
+		if !ok1 || !ok2 {
+			continue
+		}
+		s1.addResult(m.Result1, m.Result2)
+		s2.addResult(m.Result2, m.Result1)
+	}
+
+	sort.Sort(StandingsByRank(table))
+
+	for i, s := range table {
+		if len(played) == len(matches) {
+			// the group is over.
+			if i < groupQualifiers {
+				s.Status = StandingQualified
+			} else {
+				s.Status = StandingEliminated
+			}
+			continue
+		}
+		// teams that can still reach the points of s and teams that s cannot reach anymore.
+		catchers, ahead := 0, 0
+		maxPoints := s.Points + 3*remaining[s.Team.Id]
+		for _, o := range table {
+			if o == s {
+				continue
+			}
+			if o.Points+3*remaining[o.Team.Id] >= s.Points {
+				catchers++
+			}
+			if o.Points > maxPoints {
+				ahead++
+			}
+		}
+		if catchers < groupQualifiers {
+			s.Status = StandingQualified
+		} else if ahead >= groupQualifiers {
+			s.Status = StandingEliminated
+		} else {
+			s.Status = StandingContention
+		}
+	}
+	return table
+}
+
+// Add the result of a match to the standing of a team, results are added from the most recent match.
+func (s *Standing) addResult(goalsF, goalsA int64) {
+	s.Played++
+	s.GoalsF += goalsF
+	s.GoalsA += goalsA
+	s.GoalDiff = s.GoalsF - s.GoalsA
+
+	result := "D"
+	if goalsF > goalsA {
+		s.Won++
+		s.Points += 3
+		result = "W"
+	} else if goalsF < goalsA {
+		s.Lost++
+		result = "L"
+	} else {
+		s.Drawn++
+		s.Points++
+	}
+	if len(s.Form) < formLength {
+		s.Form = append(s.Form, result)
+	}
+}
+
+// StandingsByRank type implements the sort.Interface for []*Standing,
+// teams are ranked by points, goal difference, goals scored and name.
+type StandingsByRank []*Standing
+
+func (a StandingsByRank) Len() int      { return len(a) }
+func (a StandingsByRank) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a StandingsByRank) Less(i, j int) bool {
+	if a[i].Points != a[j].Points {
+		return a[i].Points > a[j].Points
+	}
+	if a[i].GoalDiff != a[j].GoalDiff {
+		return a[i].GoalDiff > a[j].GoalDiff
+	}
+	if a[i].GoalsF != a[j].GoalsF {
+		return a[i].GoalsF > a[j].GoalsF
+	}
+	return a[i].Team.Name < a[j].Team.Name
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStandings(t *testing.T) {
+	teams := []Tteam{{Id: 1, Name: "Brazil", Iso: "br"}, {Id: 2, Name: "Croatia", Iso: "hr"}, {Id: 3, Name: "Mexico", Iso: "mx"}, {Id: 4, Name: "Cameroon", Iso: "cm"}}
+	day := func(d int) time.Time { return time.Date(2014, time.June, d, 0, 0, 0, 0, time.UTC) }
+	match := func(d int, team1, team2, result1, result2 int64, finished bool) *Tmatch {
+		return &Tmatch{Date: day(d), TeamId1: team1, TeamId2: team2, Result1: result1, Result2: result2, Finished: finished}
+	}
+
+	matches := []*Tmatch{
+		match(12, 1, 2, 3, 1, true),
+		match(13, 3, 4, 1, 0, true),
+		match(17, 1, 3, 0, 0, true),
+		match(18, 4, 2, 0, 4, true),
+		match(23, 4, 1, 0, 0, false),
+		match(23, 2, 3, 0, 0, false),
+	}
+
+	table := standings(teams, matches)
+	wantNames := []string{"Brazil", "Mexico", "Croatia", "Cameroon"}
+	wantStatus := []string{StandingContention, StandingContention, StandingContention, StandingEliminated}
+	for i, s := range table {
+		if s.Team.Name != wantNames[i] || s.Status != wantStatus[i] {
+			t.Errorf("standings: row %d got %s %s wanted %s %s", i, s.Team.Name, s.Status, wantNames[i], wantStatus[i])
+		}
+	}
+	if b := table[0]; b.Played != 2 || b.Won != 1 || b.Drawn != 1 || b.Lost != 0 || b.GoalDiff != 2 || b.Points != 4 || !reflect.DeepEqual(b.Form, []string{"D", "W"}) {
+		t.Errorf("standings: got Brazil %+v", *b)
+	}
+
+	// the group is over.
+	matches[4].Result1, matches[4].Result2, matches[4].Finished = 1, 4, true
+	matches[5].Result1, matches[5].Result2, matches[5].Finished = 1, 3, true
+	table = standings(teams, matches)
+	wantNames = []string{"Brazil", "Mexico", "Croatia", "Cameroon"}
+	wantStatus = []string{StandingQualified, StandingQualified, StandingEliminated, StandingEliminated}
+	for i, s := range table {
+		if s.Team.Name != wantNames[i] || s.Status != wantStatus[i] {
+			t.Errorf("standings: row %d got %s %s wanted %s %s", i, s.Team.Name, s.Status, wantNames[i], wantStatus[i])
+		}
+	}
+	if !reflect.DeepEqual(table[0].Form, []string{"W", "D", "W"}) {
+		t.Errorf("standings: got Brazil form %v", table[0].Form)
+	}
+}
@@ -27,9 +27,10 @@ import (
 )
 
 type Tteam struct {
-	Id   int64
-	Name string
-	Iso  string
+	Id       int64
+	Name     string
+	Iso      string
+	Strength float64 // strength rating used to simulate matches, 0 is an average team.
 }
 
 // Get a Tteam entity by id.
@@ -125,7 +125,7 @@ func (wct WorldCupTournament) MapOfGroupMatches() map[string][][]string {
 	mB1 := []string{"3", "Jun/13/2014", "Spain", "Netherlands", "Arena Fonte Nova, Salvador"}
 	mB2 := []string{"4", "Jun/13/2014", "Chile", "Australia", "Arena Pantanal, Cuiabá"}
 	mB3 := []string{"19", "Jun/18/2014", "Spain", "Chile", "Estádio do Maracanã, Rio de Janeiro"}
-	mB4 := []string{"20", "Jun/18/2014", "Australia", "Netherlands", "Estádio BeiraRio, Porto Alegre"}
+	mB4 := []string{"20", "Jun/18/2014", "Australia", "Netherlands", "Estádio Beira-Rio, Porto Alegre"}
 	mB5 := []string{"35", "Jun/23/2014", "Australia", "Spain", "Curitiba"}
 	mB6 := []string{"36", "Jun/23/2014", "Netherlands", "Chile", "São Paulo"}
 
@@ -144,7 +144,7 @@ func (wct WorldCupTournament) MapOfGroupMatches() map[string][][]string {
 	mD6 := []string{"40", "Jun/24/2014", "Costa Rica", "England", "Belo Horizonte"}
 
 	mE1 := []string{"9", "Jun/15/2014", "Switzerland", "Ecuador", "Estádio Nacional Mané Garrincha, Brasília"}
-	mE2 := []string{"10", "Jun/15/2014", "France", "Honduras", "Estádio BeiraRio, Porto Alegre"}
+	mE2 := []string{"10", "Jun/15/2014", "France", "Honduras", "Estádio Beira-Rio, Porto Alegre"}
 	mE3 := []string{"25", "Jun/20/2014", "Switzerland", "France", "Arena Fonte Nova, Salvador"}
 	mE4 := []string{"26", "Jun/20/2014", "Honduras", "Ecuador", "Arena da Baixada, Curitiba"}
 	mE5 := []string{"41", "Jun/25/2014", "Honduras", "Switzerland", "Manaus"}
@@ -259,6 +259,24 @@ func (wct WorldCupTournament) ArrayOfPhases() []string {
 	return []string{cFirstStage, cRoundOf16, cQuarterFinals, cSemiFinals, cThirdPlace, cFinals}
 }
 
+// Return an array of the venues of the world cup tournament.
+func (wct WorldCupTournament) ArrayOfVenues() []Venue {
+	return []Venue{
+		{0, "Estádio Mineirão", "Belo Horizonte", "Brazil", "America/Sao_Paulo", 58170, -19.8658, -43.9711},
+		{0, "Estádio Nacional Mané Garrincha", "Brasília", "Brazil", "America/Sao_Paulo", 69432, -15.7835, -47.8992},
+		{0, "Arena Pantanal", "Cuiabá", "Brazil", "America/Cuiaba", 41112, -15.6039, -56.1206},
+		{0, "Arena da Baixada", "Curitiba", "Brazil", "America/Sao_Paulo", 39631, -25.4482, -49.2769},
+		{0, "Estádio Castelão", "Fortaleza", "Brazil", "America/Fortaleza", 60342, -3.8071, -38.5225},
+		{0, "Arena Amazônia", "Manaus", "Brazil", "America/Manaus", 40549, -3.0833, -60.0281},
+		{0, "Estádio das Dunas", "Natal", "Brazil", "America/Fortaleza", 39971, -5.8281, -35.2128},
+		{0, "Estádio Beira-Rio", "Porto Alegre", "Brazil", "America/Sao_Paulo", 43394, -30.0656, -51.2358},
+		{0, "Arena Pernambuco", "Recife", "Brazil", "America/Recife", 42610, -8.0403, -35.0081},
+		{0, "Estádio do Maracanã", "Rio de Janeiro", "Brazil", "America/Sao_Paulo", 74738, -22.9122, -43.2302},
+		{0, "Arena Fonte Nova", "Salvador", "Brazil", "America/Bahia", 48747, -12.9786, -38.5043},
+		{0, "Arena de São Paulo", "São Paulo", "Brazil", "America/Sao_Paulo", 62601, -23.5453, -46.4742},
+	}
+}
+
 // Build a map with key the corresponding phase in the world cup tournament
 // at value a tuple that represent the match number interval in which the phase take place:
 // first stage: matches 1 to 48
@@ -286,6 +304,14 @@ func CreateWorldCup(c appengine.Context, adminId int64) (*Tournament, error) {
 
 	wct := WorldCupTournament{}
 
+	// venues of the tournament, created first so that matches can reference them.
+	venues := wct.ArrayOfVenues()
+	venueIds, errv := CreateVenues(c, venues)
+	if errv != nil {
+		return nil, errv
+	}
+	log.Infof(c, "World Cup: venues: %v put in datastore ok", venueIds)
+
 	mapWCGroups := wct.MapOfGroups()
 	mapCountryCodes := wct.MapOfTeamCodes()
 	mapTeamId := make(map[string]int64)
@@ -341,7 +367,7 @@ func CreateWorldCup(c appengine.Context, adminId int64) (*Tournament, error) {
 			teamkey := datastore.NewKey(c, "Tteam", "", teamID, nil)
 			log.Infof(c, "World Cup: team: %v NewKey ok", teamName)
 
-			team := &Tteam{teamID, teamName, mapCountryCodes[teamName]}
+			team := &Tteam{teamID, teamName, mapCountryCodes[teamName], 0}
 			log.Infof(c, "World Cup: team: %v instance of team ok", teamName)
 
 			_, err := datastore.Put(c, teamkey, team)
@@ -372,7 +398,7 @@ func CreateWorldCup(c appengine.Context, adminId int64) (*Tournament, error) {
 			matchkey := datastore.NewKey(c, "Tmatch", "", matchID, nil)
 			log.Infof(c, "World Cup: match: new key ok")
 
-			matchTime, _ := time.Parse(shortForm, matchData[cMatchDate])
+			matchTime := parseMatchDate(wct, matchData[cMatchDate], matchData[cMatchLocation])
 			matchInternalId, _ := strconv.Atoi(matchData[cMatchId])
 			emptyrule := ""
 			emptyresult := int64(0)
@@ -389,6 +415,9 @@ func CreateWorldCup(c appengine.Context, adminId int64) (*Tournament, error) {
 				false,
 				true,
 				true,
+				time.Time{},
+				0,
+				venueIdOf(venues, matchData[cMatchLocation]),
 			}
 			log.Infof(c, "World Cup: match: build match ok")
 
@@ -456,7 +485,7 @@ func CreateWorldCup(c appengine.Context, adminId int64) (*Tournament, error) {
 			matchkey := datastore.NewKey(c, "Tmatch", "", matchID, nil)
 			log.Infof(c, "World Cup: match: new key ok")
 
-			matchTime, _ := time.Parse(shortForm, matchData[cMatchDate])
+			matchTime := parseMatchDate(wct, matchData[cMatchDate], matchData[cMatchLocation])
 			matchInternalId, _ := strconv.Atoi(matchData[cMatchId])
 
 			rule := fmt.Sprintf("%s %s", matchData[cMatchTeam1], matchData[cMatchTeam2])
@@ -474,6 +503,9 @@ func CreateWorldCup(c appengine.Context, adminId int64) (*Tournament, error) {
 				false,
 				false,
 				true,
+				time.Time{},
+				0,
+				venueIdOf(venues, matchData[cMatchLocation]),
 			}
 			log.Infof(c, "World Cup: match 2nd round: build match ok")
 
@@ -503,6 +535,8 @@ func CreateWorldCup(c appengine.Context, adminId int64) (*Tournament, error) {
 		tournament.Matches2ndStage = matches2ndStageIds
 		tournament.UserIds = userIds
 		tournament.TeamIds = teamIds
+		tournament.VenueIds = venueIds
+		tournament.Format = CompetitionWorldCup
 		tournament.IsFirstStageComplete = false
 		if err1 := tournament.Update(c); err1 != nil {
 			log.Infof(c, "World Cup: unable to udpate tournament.")
@@ -45,8 +45,10 @@ type User struct {
 	Username              string
 	Name                  string
 	Alias                 string              // name to display chosen by user if requested.
+	Timezone              string              // preferred IANA timezone of the user, e.g. Europe/Paris.
 	IsAdmin               bool                // is user gonawin admin.
 	Auth                  string              // authentication auth token
+	CalendarToken         string              // secret token of the calendar feeds of the user.
 	PredictIds            []int64             // current user predicts.
 	ArchivedPredictInds   []int64             // archived user predicts.
 	TournamentIds         []int64             // current tournament ids of user <=> tournaments user subscribed.
@@ -55,6 +57,7 @@ type User struct {
 	Score                 int64               // overall user score.
 	ScoreOfTournaments    []ScoreOfTournament // ids of Scores for each tournament the user is participating on.
 	ActivityIds           []int64             // ids of user's activities
+	BadgeIds              []int64             // ids of user's badges
 	Created               time.Time
 }
 
@@ -64,6 +67,7 @@ type UserJson struct {
 	Username              *string              `json:",omitempty"`
 	Name                  *string              `json:",omitempty"`
 	Alias                 *string              `json:",omitempty"`
+	Timezone              *string              `json:",omitempty"`
 	IsAdmin               *bool                `json:",omitempty"`
 	Auth                  *string              `json:",omitempty"`
 	PredictIds            *[]int64             `json:",omitempty"`
@@ -74,6 +78,7 @@ type UserJson struct {
 	Score                 *int64               `json:",omitempty"`
 	ScoreOfTournaments    *[]ScoreOfTournament `json:",omitempty"`
 	ActivityIds           *[]int64             `json:",omitempty"`
+	BadgeIds              *[]int64             `json:",omitempty"`
 	Created               *time.Time           `json:",omitempty"`
 }
 
@@ -89,7 +94,7 @@ func CreateUser(c appengine.Context, email, username, name, alias string, isAdmi
 
 	emptyArray := make([]int64, 0)
 	emptyScores := make([]ScoreOfTournament, 0)
-	user := &User{userId, email, username, name, alias, isAdmin, auth, emptyArray, emptyArray, emptyArray, emptyArray, emptyArray, int64(0), emptyScores, emptyArray, time.Now()}
+	user := &User{userId, email, username, name, alias, "", isAdmin, auth, "", emptyArray, emptyArray, emptyArray, emptyArray, emptyArray, int64(0), emptyScores, emptyArray, emptyArray, time.Now()}
 
 	_, err = datastore.Put(c, key, user)
 	if err != nil {
@@ -244,6 +249,35 @@ func GenerateAuthKey() string {
 	return fmt.Sprintf("%x", b)
 }
 
+// Get the secret token of the calendar feeds of the user, it is generated the first time.
+func (u *User) CalendarFeedToken(c appengine.Context) (string, error) {
+	if len(u.CalendarToken) > 0 {
+		return u.CalendarToken, nil
+	}
+	return u.ResetCalendarToken(c)
+}
+
+// Generate a new secret token of the calendar feeds of the user, the previous one is revoked.
+func (u *User) ResetCalendarToken(c appengine.Context) (string, error) {
+	token := GenerateAuthKey()
+	if len(token) == 0 {
+		return "", errors.New("model/user: unable to generate calendar token")
+	}
+	u.CalendarToken = token
+	if err := u.Update(c); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Find a user given the secret token of his calendar feeds.
+func UserByCalendarToken(c appengine.Context, token string) *User {
+	if len(token) == 0 {
+		return nil
+	}
+	return FindUser(c, "CalendarToken", token)
+}
+
 // From a user id returns an array of teams the user iq involved participates.
 func (u *User) Teams(c appengine.Context) []*Team {
 
@@ -291,6 +325,22 @@ func (u *User) TournamentsByPage(c appengine.Context, count, page int64) []*Tour
 	return paged
 }
 
+// Get a page of the archived tournaments of the user, the last archived first.
+func (u *User) ArchivedTournamentsByPage(c appengine.Context, count, page int64) []*Tournament {
+	tournaments := u.ArchivedTournaments(c)
+	start, end := calculateStartAndEnd(int64(len(tournaments)), count, page)
+	var paged []*Tournament
+	for i := start; i >= end; i-- {
+		paged = append(paged, tournaments[i])
+	}
+	return paged
+}
+
+// Get the ids of all the predicts of the user, current and archived.
+func (u *User) AllPredictIds() []int64 {
+	return append(append([]int64{}, u.PredictIds...), u.ArchivedPredictInds...)
+}
+
 // Adds a predict Id in the PredictId array.
 func (u *User) AddPredictId(c appengine.Context, pId int64) error {
 
@@ -361,6 +411,19 @@ func (u *User) Tournaments(c appengine.Context) []*Tournament {
 	return tournaments
 }
 
+// from a user return an array of the finished tournaments the user was involved in.
+func (u *User) ArchivedTournaments(c appengine.Context) []*Tournament {
+	var tournaments []*Tournament
+	for _, tId := range u.ArchivedTournamentIds {
+		if t, err := TournamentById(c, tId); err != nil {
+			log.Errorf(c, " ArchivedTournaments, cannot find tournament with ID=%d", tId)
+		} else {
+			tournaments = append(tournaments, t)
+		}
+	}
+	return tournaments
+}
+
 // Adds a team Id in the TeamId array.
 func (u *User) AddTeamId(c appengine.Context, tId int64) error {
 
@@ -441,6 +504,46 @@ func (u *User) ScoreForMatch(c appengine.Context, m *Tmatch) (int64, error) {
 	return computeScore(c, m, p), nil
 }
 
+// Maximum number of predicts read in a single batch.
+const predictsBatchSize = 500
+
+// Get the date of the last predict submitted by each user for the given matches, by user id.
+// Users without predicts for these matches are not in the map.
+func lastSubmissions(predicts []*Predict, matchIds []int64) map[int64]time.Time {
+	matches := make(map[int64]bool)
+	for _, id := range matchIds {
+		matches[id] = true
+	}
+	last := make(map[int64]time.Time)
+	for _, p := range predicts {
+		if matches[p.MatchId] && p.SubmittedAt().After(last[p.UserId]) {
+			last[p.UserId] = p.SubmittedAt()
+		}
+	}
+	return last
+}
+
+// Sort users by score, ties are broken by submission time:
+// the user who submitted his last predict first is ranked higher.
+type UserByScoreAndSubmission struct {
+	Users       []*User
+	Submissions map[int64]time.Time // date of last predict submission by user id.
+}
+
+func (a UserByScoreAndSubmission) Len() int      { return len(a.Users) }
+func (a UserByScoreAndSubmission) Swap(i, j int) { a.Users[i], a.Users[j] = a.Users[j], a.Users[i] }
+func (a UserByScoreAndSubmission) Less(i, j int) bool {
+	if a.Users[i].Score != a.Users[j].Score {
+		return a.Users[i].Score < a.Users[j].Score
+	}
+	si, sj := a.Submissions[a.Users[i].Id], a.Submissions[a.Users[j].Id]
+	if si.IsZero() || sj.IsZero() {
+		// users without predicts rank lower.
+		return si.IsZero() && !sj.IsZero()
+	}
+	return si.After(sj)
+}
+
 // Sort users by score
 type UserByScore []*User
 
@@ -550,7 +653,7 @@ func (u *User) ScoreByTournament(c appengine.Context, tId int64) int64 {
 	for _, s := range u.ScoreOfTournaments {
 		if s.TournamentId == tId {
 			if score, err := ScoreById(c, s.ScoreId); err == nil {
-				return sumInt64(&score.Scores)
+				return score.Total()
 			}
 		}
 	}
@@ -569,10 +672,14 @@ func (u *User) TournamentsScores(c appengine.Context) []*ScoreOverall {
 			so.Id = score.Id
 			so.UserId = score.UserId
 			so.TournamentId = score.TournamentId
-			so.Score = sumInt64(&score.Scores)
+			so.Score = score.Total()
 			if len(score.Scores) > 0 {
 				so.LastProgression = score.Scores[len(score.Scores)-1]
 			}
+			if len(score.SidePoints) > 0 {
+				so.LastProgression += score.SidePoints[len(score.SidePoints)-1]
+			}
+			so.Bonus = sumInt64(&score.Bonuses)
 			scores = append(scores, &so)
 		}
 	}
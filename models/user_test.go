@@ -16,10 +16,38 @@
 
 package models
 
-// import (
-// 	"testing"
-// )
+import (
+	"sort"
+	"testing"
+	"time"
+)
 
 // func TestFetchUserInfo(t *testing.T) {
 //  	t.Fatalf("Not implemented")
 // }
+
+func TestUserByScoreAndSubmission(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2014, time.June, d, 12, 0, 0, 0, time.UTC) }
+	predicts := []*Predict{
+		{UserId: 1, MatchId: 10, Created: day(10)},
+		{UserId: 1, MatchId: 11, Created: day(11), Updated: day(14)},
+		{UserId: 2, MatchId: 10, Created: day(12)},
+		{UserId: 3, MatchId: 99, Created: day(1)}, // predict of another tournament.
+		{UserId: 4, MatchId: 11, Created: day(13)},
+	}
+	submissions := lastSubmissions(predicts, []int64{10, 11})
+	if len(submissions) != 3 || !submissions[1].Equal(day(14)) || !submissions[2].Equal(day(12)) {
+		t.Fatalf("lastSubmissions: got %v", submissions)
+	}
+
+	users := []*User{{Id: 1, Score: 5}, {Id: 2, Score: 5}, {Id: 3, Score: 5}, {Id: 4, Score: 7}}
+	sort.Sort(UserByScoreAndSubmission{users, submissions})
+
+	// sorted in ascending order, the leader is last.
+	want := []int64{3, 1, 2, 4}
+	for i, u := range users {
+		if u.Id != want[i] {
+			t.Errorf("rank %d: got user %d wanted user %d", i, u.Id, want[i])
+		}
+	}
+}
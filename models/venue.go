@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"appengine"
+	"appengine/datastore"
+
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// A Venue is a stadium where the matches of a tournament take place.
+type Venue struct {
+	Id        int64
+	Name      string // name of the stadium.
+	City      string
+	Country   string
+	Timezone  string  // IANA timezone of the venue, e.g. America/Sao_Paulo.
+	Capacity  int64   // number of seats.
+	Latitude  float64 // coordinates of the venue in decimal degrees.
+	Longitude float64
+}
+
+// Create venue entities given an array of venues, ids are allocated for them.
+func CreateVenues(c appengine.Context, venues []Venue) ([]int64, error) {
+	ids := make([]int64, len(venues))
+	if len(venues) == 0 {
+		return ids, nil
+	}
+
+	low, _, err := datastore.AllocateIDs(c, "Venue", nil, len(venues))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*datastore.Key, len(venues))
+	for i, _ := range venues {
+		venues[i].Id = low + int64(i)
+		ids[i] = venues[i].Id
+		keys[i] = VenueKeyById(c, venues[i].Id)
+	}
+	if _, err := datastore.PutMulti(c, keys, venues); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Get pointer to a venue key given a venue id.
+func VenueKeyById(c appengine.Context, id int64) *datastore.Key {
+	return datastore.NewKey(c, "Venue", "", id, nil)
+}
+
+// Get a venue entity by id.
+func VenueById(c appengine.Context, id int64) (*Venue, error) {
+	var v Venue
+	if err := datastore.Get(c, VenueKeyById(c, id), &v); err != nil {
+		log.Errorf(c, "venue not found : %v", err)
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Get an array of venue entities from an array of venue ids.
+func Venues(c appengine.Context, ids []int64) []*Venue {
+	var venues []*Venue
+	for _, id := range ids {
+		if v, err := VenueById(c, id); err != nil {
+			log.Errorf(c, "Venues, cannot find venue with ID=%d", id)
+		} else {
+			venues = append(venues, v)
+		}
+	}
+	return venues
+}
+
+// Destroy an array of venues given their ids.
+func DestroyVenues(c appengine.Context, ids []int64) error {
+	keys := make([]*datastore.Key, len(ids))
+	for i, _ := range keys {
+		keys[i] = VenueKeyById(c, ids[i])
+	}
+	return datastore.DeleteMulti(c, keys)
+}
+
+// Get the id of the venue of a match location, 0 if the location has no venue.
+func venueIdOf(venues []Venue, location string) int64 {
+	if v := venueOf(venues, location); v != nil {
+		return v.Id
+	}
+	return 0
+}
+
+// Find the venue of a match location. A location is either a city or a stadium followed by its city:
+// "Arena da Baixada, Curitiba". The stadium is looked up first, then the city.
+func venueOf(venues []Venue, location string) *Venue {
+	name := strings.TrimSpace(strings.Split(location, ",")[0])
+	city := venueCity(location)
+	for i, v := range venues {
+		if strings.EqualFold(v.Name, name) {
+			return &venues[i]
+		}
+	}
+	for i, v := range venues {
+		if strings.EqualFold(v.City, city) {
+			return &venues[i]
+		}
+	}
+	return nil
+}
+
+// Get the matches of a tournament that take place in a venue, sorted by date.
+func (t *Tournament) VenueMatches(c appengine.Context, venueId int64) []*Tmatch {
+	var matches []*Tmatch
+	for _, m := range GetAllMatchesFromTournament(c, t) {
+		if m.VenueId == venueId {
+			matches = append(matches, m)
+		}
+	}
+	sort.Sort(MatchByDate(matches))
+	return matches
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import "testing"
+
+func TestVenueOf(t *testing.T) {
+	venues := ChampionsLeagueTournament{}.ArrayOfVenues()
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"Parc des Princes, Paris", "Parc des Princes"},
+		{"Stade Santiago Bernabéu, Madrid", "Stade Santiago Bernabéu"},
+		{"Stade Vicente-Calderón, Madrid", "Stade Vicente-Calderón"},
+		{"Berlin", "Olympiastadion"},
+		{"TBD", ""},
+	}
+	for _, test := range tests {
+		got := ""
+		if v := venueOf(venues, test.location); v != nil {
+			got = v.Name
+		}
+		if got != test.want {
+			t.Errorf("venueOf(%q): got %q wanted %q", test.location, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"time"
+
+	"appengine"
+	"appengine/datastore"
+	"appengine/taskqueue"
+
+	"github.com/santiaago/gonawin/helpers"
+	"github.com/santiaago/gonawin/helpers/log"
+)
+
+// Outcomes of a match a wager can be placed on.
+const (
+	OutcomeDraw  = 0 // draw.
+	OutcomeTeam1 = 1 // first team wins.
+	OutcomeTeam2 = 2 // second team wins.
+)
+
+// Kinds of ledger entries.
+const (
+	LedgerCredit = "credit" // initial credits of the tournament.
+	LedgerStake  = "stake"  // credits staked on a wager.
+	LedgerPayout = "payout" // credits won by a wager.
+)
+
+// Default number of credits given to each participant of a tournament in wagering mode.
+const defaultInitialCredits = 1000
+
+// Bounds of the odds derived from the crowd consensus.
+const (
+	minOdds = 1.05
+	maxOdds = 50
+)
+
+// Minimum number of predicts to derive the odds of a match, with fewer predicts the odds are neutral
+// so that they do not reveal the predict of a single user.
+const minOddsPredicts = 5
+
+// A Wallet entity holds the virtual credits of a user in a tournament.
+//
+// The wallet is the root of an entity group with its wagers and ledger entries,
+// so that balance updates are done in a transaction with the entries that justify them.
+type Wallet struct {
+	KeyName      string // user id and tournament id.
+	UserId       int64
+	TournamentId int64
+	Balance      int64
+	Created      time.Time
+}
+
+// A Wager entity is a stake of credits on the outcome of a match at given odds.
+type Wager struct {
+	Id           int64
+	UserId       int64
+	TournamentId int64
+	MatchId      int64
+	Outcome      int64
+	Stake        int64
+	Odds         float64 // odds when the wager was placed.
+	Settled      bool
+	Payout       int64
+	Created      time.Time
+}
+
+// A LedgerEntry entity records a change of the balance of a wallet.
+type LedgerEntry struct {
+	Id      int64
+	Kind    string
+	WagerId int64 // wager of a stake or a payout, 0 otherwise.
+	Amount  int64 // signed change of the balance.
+	Balance int64 // balance after the change.
+	Created time.Time
+}
+
+// A MatchOdds entity holds the odds of a match set by a tournament admin.
+type MatchOdds struct {
+	MatchId int64
+	Odds1   float64 // odds of first team winning.
+	OddsX   float64 // odds of a draw.
+	Odds2   float64 // odds of second team winning.
+	Set     bool    // odds are set by an admin, derived from the crowd consensus otherwise.
+}
+
+// Number of credits given to each participant of the tournament.
+func (t *Tournament) Credits() int64 {
+	if t.InitialCredits <= 0 {
+		return defaultInitialCredits
+	}
+	return t.InitialCredits
+}
+
+// Get a Wallet key given a user id and a tournament id.
+func WalletKey(c appengine.Context, userId, tournamentId int64) *datastore.Key {
+	return datastore.NewKey(c, "Wallet", walletKeyName(userId, tournamentId), 0, nil)
+}
+
+func walletKeyName(userId, tournamentId int64) string {
+	return fmt.Sprintf("%d-%d", userId, tournamentId)
+}
+
+// Get the wallet of a user in a tournament, it is created with the initial credits of the tournament if needed.
+func (t *Tournament) Wallet(c appengine.Context, userId int64) (*Wallet, error) {
+	var w Wallet
+	key := WalletKey(c, userId, t.Id)
+	err := datastore.RunInTransaction(c, func(c appengine.Context) error {
+		err := datastore.Get(c, key, &w)
+		if err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		now := time.Now()
+		w = Wallet{walletKeyName(userId, t.Id), userId, t.Id, t.Credits(), now}
+		if _, err = datastore.Put(c, key, &w); err != nil {
+			return err
+		}
+		_, err = putLedgerEntry(c, key, LedgerCredit, 0, w.Balance, w.Balance)
+		return err
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Save a ledger entry in the entity group of a wallet.
+func putLedgerEntry(c appengine.Context, walletKey *datastore.Key, kind string, wagerId, amount, balance int64) (*LedgerEntry, error) {
+	id, _, err := datastore.AllocateIDs(c, "LedgerEntry", walletKey, 1)
+	if err != nil {
+		return nil, err
+	}
+	e := &LedgerEntry{id, kind, wagerId, amount, balance, time.Now()}
+	if _, err = datastore.Put(c, datastore.NewKey(c, "LedgerEntry", "", id, walletKey), e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Get the ledger entries of a wallet sorted by date.
+func (w *Wallet) Ledger(c appengine.Context) []*LedgerEntry {
+	q := datastore.NewQuery("LedgerEntry").Ancestor(WalletKey(c, w.UserId, w.TournamentId))
+
+	var entries []*LedgerEntry
+	if _, err := q.GetAll(c, &entries); err != nil {
+		log.Errorf(c, "Wallet.Ledger: error occurred during GetAll: %v", err)
+		return nil
+	}
+	sort.Sort(LedgerEntryByDate(entries))
+	return entries
+}
+
+// Get the wagers of a wallet.
+func (w *Wallet) Wagers(c appengine.Context) []*Wager {
+	q := datastore.NewQuery("Wager").Ancestor(WalletKey(c, w.UserId, w.TournamentId))
+
+	var wagers []*Wager
+	if _, err := q.GetAll(c, &wagers); err != nil {
+		log.Errorf(c, "Wallet.Wagers: error occurred during GetAll: %v", err)
+		return nil
+	}
+	return wagers
+}
+
+// Place a wager on the outcome of a match.
+// The stake is taken from the wallet of the user in a transaction.
+func (t *Tournament) PlaceWager(c appengine.Context, userId int64, m *Tmatch, outcome, stake int64, odds float64) (*Wager, error) {
+	if outcome < OutcomeDraw || outcome > OutcomeTeam2 || stake <= 0 {
+		return nil, errors.New(helpers.ErrorCodeWagerInvalid)
+	}
+	if _, err := t.Wallet(c, userId); err != nil {
+		return nil, err
+	}
+
+	var wager *Wager
+	key := WalletKey(c, userId, t.Id)
+	err := datastore.RunInTransaction(c, func(c appengine.Context) error {
+		var w Wallet
+		if err := datastore.Get(c, key, &w); err != nil {
+			return err
+		}
+		if w.Balance < stake {
+			return errors.New(helpers.ErrorCodeWagerNotEnoughCredits)
+		}
+		w.Balance -= stake
+
+		id, _, err := datastore.AllocateIDs(c, "Wager", key, 1)
+		if err != nil {
+			return err
+		}
+		wager = &Wager{id, userId, t.Id, m.Id, outcome, stake, odds, false, 0, time.Now()}
+		if _, err = datastore.Put(c, datastore.NewKey(c, "Wager", "", id, key), wager); err != nil {
+			return err
+		}
+		if _, err = datastore.Put(c, key, &w); err != nil {
+			return err
+		}
+		_, err = putLedgerEntry(c, key, LedgerStake, id, -stake, w.Balance)
+		return err
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return wager, nil
+}
+
+// Outcome of a finished match.
+func (m *Tmatch) Outcome() int64 {
+	if m.Result1 > m.Result2 {
+		return OutcomeTeam1
+	} else if m.Result1 < m.Result2 {
+		return OutcomeTeam2
+	}
+	return OutcomeDraw
+}
+
+// Credits won by a wager given a finished match.
+func (wa *Wager) PayoutFor(m *Tmatch) int64 {
+	if wa.Outcome != m.Outcome() {
+		return 0
+	}
+	return int64(math.Floor(float64(wa.Stake)*wa.Odds + 0.5))
+}
+
+// Settle the wagers of a finished match, the payouts are credited to the wallets.
+// A wager is settled only once, so the settlement can safely be run again.
+func (t *Tournament) SettleWagers(c appengine.Context, m *Tmatch) error {
+	q := datastore.NewQuery("Wager").
+		Filter("MatchId"+" =", m.Id).
+		Filter("Settled"+" =", false)
+
+	var wagers []*Wager
+	keys, err := q.GetAll(c, &wagers)
+	if err != nil {
+		return err
+	}
+
+	for i := range wagers {
+		wagerKey := keys[i]
+		walletKey := wagerKey.Parent()
+		err := datastore.RunInTransaction(c, func(c appengine.Context) error {
+			var wa Wager
+			if err := datastore.Get(c, wagerKey, &wa); err != nil {
+				return err
+			}
+			if wa.Settled {
+				return nil
+			}
+			var w Wallet
+			if err := datastore.Get(c, walletKey, &w); err != nil {
+				return err
+			}
+			wa.Settled = true
+			wa.Payout = wa.PayoutFor(m)
+			if _, err := datastore.Put(c, wagerKey, &wa); err != nil {
+				return err
+			}
+			if wa.Payout == 0 {
+				return nil
+			}
+			w.Balance += wa.Payout
+			if _, err := datastore.Put(c, walletKey, &w); err != nil {
+				return err
+			}
+			_, err := putLedgerEntry(c, walletKey, LedgerPayout, wa.Id, wa.Payout, w.Balance)
+			return err
+		}, nil)
+		if err != nil {
+			log.Errorf(c, "SettleWagers: unable to settle wager %v: %v", wagers[i].Id, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Send a task to settle the wagers of a finished match.
+func (t *Tournament) QueueSettleWagers(c appengine.Context, m *Tmatch) error {
+	desc := "Queue settle wagers:"
+	log.Infof(c, "%s Sending to taskqueue: settle wagers", desc)
+
+	b1, errm := json.Marshal(t)
+	if errm != nil {
+		log.Errorf(c, "%s Error marshaling", desc, errm)
+	}
+	b2, errm2 := json.Marshal(m)
+	if errm2 != nil {
+		log.Errorf(c, "%s Error marshaling", desc, errm2)
+	}
+
+	task := taskqueue.NewPOSTTask("/a/settle/wagers/", url.Values{
+		"tournament": []string{string(b1)},
+		"match":      []string{string(b2)},
+	})
+
+	if _, err := taskqueue.Add(c, task, "gw-queue"); err != nil {
+		log.Errorf(c, "%s unable to add task to taskqueue.", desc)
+		return err
+	}
+	log.Infof(c, "%s add task to taskqueue successfully", desc)
+	return nil
+}
+
+// Get a MatchOdds key given a match id.
+func MatchOddsKey(c appengine.Context, matchId int64) *datastore.Key {
+	return datastore.NewKey(c, "MatchOdds", "", matchId, nil)
+}
+
+// Set the odds of a match.
+func SetMatchOdds(c appengine.Context, matchId int64, odds1, oddsX, odds2 float64) (*MatchOdds, error) {
+	o := &MatchOdds{matchId, odds1, oddsX, odds2, true}
+	if _, err := datastore.Put(c, MatchOddsKey(c, matchId), o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Get the odds of a match: the odds set by an admin, or the odds derived from the predicts of the participants.
+// Derived odds only use the number of predicts of each outcome, so they can be given before the match is locked.
+func (t *Tournament) Odds(c appengine.Context, m *Tmatch) *MatchOdds {
+	var o MatchOdds
+	if err := datastore.Get(c, MatchOddsKey(c, m.Id), &o); err == nil && o.Set {
+		return &o
+	}
+	cs := NewConsensus(m.Id, ConsensusScopeTournament, t.Id, predictsOfUsers(FindPredicts(c, "MatchId", m.Id), t.UserIds))
+	return oddsFromConsensus(cs)
+}
+
+// Odds of an outcome.
+func (o *MatchOdds) Of(outcome int64) float64 {
+	switch outcome {
+	case OutcomeTeam1:
+		return o.Odds1
+	case OutcomeTeam2:
+		return o.Odds2
+	}
+	return o.OddsX
+}
+
+// Derive the odds of a match from a crowd consensus.
+// The probability of each outcome is smoothed so that an outcome nobody predicted still gets finite odds.
+// The odds are neutral while there are too few predicts.
+func oddsFromConsensus(cs *Consensus) *MatchOdds {
+	if cs.Predicts < minOddsPredicts {
+		return &MatchOdds{cs.MatchId, 3, 3, 3, false}
+	}
+	total := float64(cs.Predicts + 3)
+	odds := func(count int64) float64 {
+		o := total / float64(count+1)
+		o = math.Floor(o*100+0.5) / 100
+		return math.Max(minOdds, math.Min(maxOdds, o))
+	}
+	return &MatchOdds{cs.MatchId, odds(cs.Wins1), odds(cs.Draws), odds(cs.Wins2), false}
+}
+
+// Get the wallets of the participants of the tournament sorted by balance.
+func (t *Tournament) RankingByCredits(c appengine.Context, limit int) []*Wallet {
+	q := datastore.NewQuery("Wallet").Filter("TournamentId"+" =", t.Id)
+
+	var wallets []*Wallet
+	if _, err := q.GetAll(c, &wallets); err != nil {
+		log.Errorf(c, "Tournament.RankingByCredits: error occurred during GetAll: %v", err)
+		return nil
+	}
+	sort.Sort(sort.Reverse(WalletByBalance(wallets)))
+	if len(wallets) > limit {
+		return wallets[:limit]
+	}
+	return wallets
+}
+
+// WalletByBalance type implements the sort.Interface for []*Wallet based on the Balance field.
+type WalletByBalance []*Wallet
+
+func (a WalletByBalance) Len() int           { return len(a) }
+func (a WalletByBalance) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a WalletByBalance) Less(i, j int) bool { return a[i].Balance < a[j].Balance }
+
+// LedgerEntryByDate type implements the sort.Interface for []*LedgerEntry based on the Created field.
+type LedgerEntryByDate []*LedgerEntry
+
+func (a LedgerEntryByDate) Len() int           { return len(a) }
+func (a LedgerEntryByDate) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a LedgerEntryByDate) Less(i, j int) bool { return a[i].Created.Before(a[j].Created) }
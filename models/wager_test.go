@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2014 Santiago Arias | Remy Jourde
+ *
+ * Permission to use, copy, modify, and distribute this software for any
+ * purpose with or without fee is hereby granted, provided that the above
+ * copyright notice and this permission notice appear in all copies.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+ * WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+ * MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ * ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+ * WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ * ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+ * OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+ */
+
+package models
+
+import (
+	"testing"
+)
+
+func TestOddsFromConsensus(t *testing.T) {
+	tests := []struct {
+		name      string
+		consensus Consensus
+		want      MatchOdds
+	}{
+		{
+			name:      "No predicts",
+			consensus: Consensus{MatchId: 1},
+			want:      MatchOdds{1, 3, 3, 3, false},
+		},
+		{
+			name:      "Too few predicts",
+			consensus: Consensus{MatchId: 4, Predicts: 2, Wins1: 2},
+			want:      MatchOdds{4, 3, 3, 3, false},
+		},
+		{
+			name:      "Outcome nobody predicted",
+			consensus: Consensus{MatchId: 2, Predicts: 7, Wins1: 5, Draws: 2},
+			want:      MatchOdds{2, 1.67, 3.33, 10, false},
+		},
+		{
+			name:      "Odds bounds",
+			consensus: Consensus{MatchId: 3, Predicts: 200, Wins1: 200},
+			want:      MatchOdds{3, 1.05, 50, 50, false},
+		},
+	}
+	for _, test := range tests {
+		if got := oddsFromConsensus(&test.consensus); *got != test.want {
+			t.Errorf("oddsFromConsensus(%q): got %v wanted %v", test.name, *got, test.want)
+		}
+	}
+}
+
+func TestPayoutFor(t *testing.T) {
+	match := &Tmatch{Result1: 2, Result2: 1}
+
+	tests := []struct {
+		name  string
+		wager Wager
+		want  int64
+	}{
+		{"Winning wager", Wager{Outcome: OutcomeTeam1, Stake: 100, Odds: 1.67}, 167},
+		{"Rounded payout", Wager{Outcome: OutcomeTeam1, Stake: 3, Odds: 2.5}, 8},
+		{"Losing wager", Wager{Outcome: OutcomeDraw, Stake: 100, Odds: 3.33}, 0},
+	}
+	for _, test := range tests {
+		if got := test.wager.PayoutFor(match); got != test.want {
+			t.Errorf("PayoutFor(%q): got %d wanted %d", test.name, got, test.want)
+		}
+	}
+}